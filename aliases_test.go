@@ -0,0 +1,105 @@
+package tango
+
+import "testing"
+
+func TestAliasReadsLegacyData(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithAlias("lang", "locale"))
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', '5678', 'lang', '"es"')`); err != nil {
+		t.Error(err)
+	}
+
+	for _, key := range []string{"lang", "locale"} {
+		var result string
+		exists, err := tags.Tag("1234", "5678", key).Get(&result)
+		if err != nil {
+			t.Error(err)
+		}
+		if !exists || result != "es" {
+			t.Errorf("Expected key %s to resolve to 'es', was `%s`", key, result)
+		}
+	}
+
+	// Without rewrite, the legacy data should stay where it was.
+	list, err := tags.TagBag("1234", "5678").Tags()
+	if err != nil {
+		t.Error(err)
+	}
+	if len(list) != 1 || list[0] != "lang" {
+		t.Errorf("Expected list to be [lang], was %v", list)
+	}
+}
+
+func TestAliasRewrite(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithAlias("lang", "locale"), WithAliasRewrite())
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', '5678', 'lang', '"es"')`); err != nil {
+		t.Error(err)
+	}
+
+	// Read-only engines do not migrate the data.
+	var result string
+	if _, err := tags.ScopedWith("", ScopeOptions{ReadOnly: true}).Tag("1234", "5678", "lang").Get(&result); err != nil || result != "es" {
+		t.Errorf("Expected lang to be es, was %s (%v)", result, err)
+	}
+	if list, err := tags.TagBag("1234", "5678").Tags(); err != nil || len(list) != 1 || list[0] != "lang" {
+		t.Errorf("Expected list to be [lang], was %v (%v)", list, err)
+	}
+
+	if _, err := tags.Tag("1234", "5678", "lang").Get(&result); err != nil {
+		t.Error(err)
+	}
+	list, err := tags.TagBag("1234", "5678").Tags()
+	if err != nil {
+		t.Error(err)
+	}
+	if len(list) != 1 || list[0] != "locale" {
+		t.Errorf("Expected list to be [locale], was %v", list)
+	}
+}
+
+func TestAliasWritesNewKey(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithAlias("lang", "locale"))
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', '5678', 'lang', '"es"')`); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "5678", "lang").Set("en"); err != nil {
+		t.Error(err)
+	}
+	list, err := tags.TagBag("1234", "5678").Tags()
+	if err != nil {
+		t.Error(err)
+	}
+	if len(list) != 1 || list[0] != "locale" {
+		t.Errorf("Expected list to be [locale], was %v", list)
+	}
+
+	// Deleting should not resurrect the legacy value.
+	if err := tags.Tag("1234", "5678", "locale").Delete(); err != nil {
+		t.Error(err)
+	}
+	var result string
+	exists, err := tags.Tag("1234", "5678", "lang").Get(&result)
+	if err != nil {
+		t.Error(err)
+	}
+	if exists {
+		t.Errorf("Expected key not to exist, was `%s`", result)
+	}
+}
@@ -0,0 +1,134 @@
+package tango
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	analyzeSize = `SELECT length(value) FROM {table} ORDER BY length(value) LIMIT 1 OFFSET ?`
+)
+
+const (
+	// analyzeTop is the number of entries of the usage report of an
+	// analysis.
+	analyzeTop = 10
+
+	// analyzeUncachedOps is the number of operations after which an
+	// engine without a cache is advised to use one.
+	analyzeUncachedOps = 1000
+
+	// analyzeLowHitRate is the cache hit rate under which a cache that
+	// evicts values is advised to grow.
+	analyzeLowHitRate = 0.5
+
+	// analyzeLargeValue is the size of the values, in bytes, over which
+	// snapshots are advised to be compressed.
+	analyzeLargeValue = 1024
+
+	// analyzeDominantShare is the share of the rows over which a universe
+	// is advised to be moved into its own table.
+	analyzeDominantShare = 0.5
+
+	// analyzeDominantRows is the number of rows under which a table is too
+	// small to be split, whatever its distribution.
+	analyzeDominantRows = 100000
+)
+
+// An Analysis describes the contents of the store and how the engine has
+// been used, along with recommendations about the options that could make
+// it perform better.
+type Analysis struct {
+	// Rows and Bytes are the number of tags in the store and the size of
+	// their values.
+	Rows  int
+	Bytes int64
+
+	// MedianBytes, P99Bytes and MaxBytes describe the distribution of the
+	// size of the values.
+	MedianBytes int64
+	P99Bytes    int64
+	MaxBytes    int64
+
+	// Usage lists the largest universes, values and keys of the store.
+	Usage *UsageReport
+
+	// Recommendations are the options worth enabling according to the
+	// analysis. They are hints, not rules.
+	Recommendations []Recommendation
+}
+
+// A Recommendation is an option worth enabling, and the reason for it.
+type Recommendation struct {
+	Option string
+	Reason string
+}
+
+// Analyze inspects the store and the statistics of the engine, and returns
+// an analysis with recommendations about the options to enable, so that
+// operators have some guidance on how to tune the engine. The statistics
+// only cover the operations made by this engine since it was created, so
+// it is better to run it once the engine has been serving for a while. It
+// reads every row of the store, so it should not be run often.
+func (tags *Tags) Analyze(ctx context.Context) (*Analysis, error) {
+	if err := tags.checkUnscoped(); err != nil {
+		return nil, err
+	}
+	usage, err := tags.Usage(ctx, analyzeTop)
+	if err != nil {
+		return nil, err
+	}
+	analysis := &Analysis{Usage: usage}
+	for _, u := range usage.Universes {
+		analysis.Rows += u.Rows
+		analysis.Bytes += u.Bytes
+	}
+	if analysis.Rows > 0 {
+		sizes := []*int64{&analysis.MedianBytes, &analysis.P99Bytes, &analysis.MaxBytes}
+		offsets := []int{analysis.Rows / 2, analysis.Rows * 99 / 100, analysis.Rows - 1}
+		for i, offset := range offsets {
+			err := tags.db.QueryRowContext(ctx, tags.sql(analyzeSize), offset).Scan(sizes[i])
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return nil, err
+			}
+		}
+	}
+	analysis.Recommendations = tags.recommend(analysis)
+	return analysis, nil
+}
+
+// recommend returns the options worth enabling according to the analysis.
+func (tags *Tags) recommend(analysis *Analysis) []Recommendation {
+	var recommendations []Recommendation
+	stats := tags.Stats()
+	if tags.cache == nil && stats.Ops >= analyzeUncachedOps {
+		recommendations = append(recommendations, Recommendation{
+			Option: "WithCache",
+			Reason: fmt.Sprintf("%d operations were made without a cache", stats.Ops),
+		})
+	}
+	if tags.cache != nil && stats.CacheEvictions > 0 && stats.CacheHitRate() < analyzeLowHitRate {
+		recommendations = append(recommendations, Recommendation{
+			Option: "WithCache",
+			Reason: fmt.Sprintf("the cache hit rate is %.0f%% and %d values were evicted, so the cache may be too small", stats.CacheHitRate()*100, stats.CacheEvictions),
+		})
+	}
+	if analysis.P99Bytes >= analyzeLargeValue {
+		recommendations = append(recommendations, Recommendation{
+			Option: "SnapshotOptions.Compress",
+			Reason: fmt.Sprintf("1%% of the values are larger than %d bytes", analysis.P99Bytes),
+		})
+	}
+	if analysis.Rows >= analyzeDominantRows && len(analysis.Usage.Universes) > 1 {
+		largest := analysis.Usage.Universes[0]
+		if share := float64(largest.Bytes) / float64(analysis.Bytes); share > analyzeDominantShare {
+			recommendations = append(recommendations, Recommendation{
+				Option: "WithTable",
+				Reason: fmt.Sprintf("universe %s holds %.0f%% of the data and could have its own table", largest.Universe, share*100),
+			})
+		}
+	}
+	return recommendations
+}
@@ -0,0 +1,63 @@
+package tango
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTagsAnalyze(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	bio := `"` + strings.Repeat("a", 2000) + `"`
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', '5678', 'bio', ?),
+		('1234', '5678', 'points', '1'),
+		('4321', '5678', 'points', '22')`, bio); err != nil {
+		t.Error(err)
+	}
+	var points int
+	for i := 0; i < analyzeUncachedOps; i++ {
+		if _, err := tags.Tag("4321", "5678", "points").Get(&points); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	analysis, err := tags.Analyze(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysis.Rows != 3 || analysis.Bytes != int64(len(bio)+3) {
+		t.Errorf("Unexpected size %d rows, %d bytes", analysis.Rows, analysis.Bytes)
+	}
+	if analysis.MedianBytes != 2 || analysis.P99Bytes != int64(len(bio)) || analysis.MaxBytes != int64(len(bio)) {
+		t.Errorf("Unexpected distribution %d, %d, %d", analysis.MedianBytes, analysis.P99Bytes, analysis.MaxBytes)
+	}
+	options := []string{}
+	for _, r := range analysis.Recommendations {
+		options = append(options, r.Option)
+	}
+	if strings.Join(options, ",") != "WithCache,SnapshotOptions.Compress" {
+		t.Errorf("Expected a cache and compression to be recommended, got %+v", analysis.Recommendations)
+	}
+}
+
+func TestTagsAnalyzeEmpty(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	analysis, err := tags.Analyze(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysis.Rows != 0 || len(analysis.Recommendations) != 0 {
+		t.Errorf("Unexpected analysis %+v", analysis)
+	}
+}
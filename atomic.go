@@ -0,0 +1,154 @@
+package tango
+
+import "context"
+
+// tagEquals compares two JSON representations after normalizing them.
+var tagEquals = `SELECT json(?) = json(?)`
+
+// Swap sets the value of the tag and puts the value it held before into the
+// old variable, as a single atomic operation. It returns whether the tag
+// was set before the swap. If the tag was not set, old is left untouched.
+// The old variable may be nil if only the existence is of interest.
+func (tag *Tag) Swap(value any, old any) (bool, error) {
+	tag.warnDeprecated("set")
+	if err := tag.tags.checkUndecorated(); err != nil {
+		return false, tag.tags.failed("set", tag, err)
+	}
+	ctx, cancel := tag.tags.context(context.Background())
+	defer cancel()
+	var previous string
+	var existed bool
+	err := tag.tags.transaction(ctx, func(tx *txn) error {
+		var err error
+		previous, existed, err = tag.fetchTx(tx)
+		if err != nil {
+			return err
+		}
+		return tag.setTx(tx, value)
+	})
+	if err := tag.tags.finish("set", tag, err); err != nil {
+		return false, err
+	}
+	if existed && old != nil {
+		if err := tag.decode([]byte(previous), old); err != nil {
+			return true, tag.tags.failed("set", tag, err)
+		}
+	}
+	return existed, nil
+}
+
+// GetOrSet puts the value of the tag into the out variable, like Get, but
+// if the tag is not set, it sets the tag to the fallback value and puts
+// that into the out variable instead, as a single atomic operation, so
+// that concurrent callers all get the value of the first one. It returns
+// whether the tag was already set. Loaders and default values are not
+// used, since the fallback takes their place.
+func (tag *Tag) GetOrSet(out any, fallback any) (bool, error) {
+	tag.warnDeprecated("set")
+	if err := tag.tags.checkUndecorated(); err != nil {
+		return false, tag.tags.failed("set", tag, err)
+	}
+	ctx, cancel := tag.tags.context(context.Background())
+	defer cancel()
+	var raw string
+	var existed, stored bool
+	err := tag.tags.transaction(ctx, func(tx *txn) error {
+		var err error
+		raw, existed, err = tag.fetchTx(tx)
+		if err != nil || existed {
+			return err
+		}
+		if err := tag.setTx(tx, fallback); err != nil {
+			return err
+		}
+		// Read the value back, in case a validator rewrote it.
+		raw, stored, err = tag.fetchTx(tx)
+		return err
+	})
+	if err := tag.tags.finish("set", tag, err); err != nil {
+		return false, err
+	}
+	if existed || stored {
+		if err := tag.decode([]byte(raw), out); err != nil {
+			return existed, tag.tags.failed("get", tag, err)
+		}
+	}
+	return existed, nil
+}
+
+// fetchTx returns the JSON representation stored for this tag as part of
+// the given transaction, looking into the legacy aliases of the key if the
+// key is not set.
+func (tag *Tag) fetchTx(tx *txn) (string, bool, error) {
+	raw, exists, err := tag.fetchKey(tx.ctx, tx, tag.key)
+	if err != nil || exists {
+		return raw, exists, err
+	}
+	for _, legacy := range tag.tags.legacy[tag.key] {
+		raw, exists, err := tag.fetchKey(tx.ctx, tx, legacy)
+		if err != nil || exists {
+			return raw, exists, err
+		}
+	}
+	return "", false, nil
+}
+
+// DeleteExisting removes the value of the tag, like Delete, but it also
+// reports whether the tag was set and has actually been removed.
+func (tag *Tag) DeleteExisting() (bool, error) {
+	tag.warnDeprecated("delete")
+	if err := tag.tags.checkUndecorated(); err != nil {
+		return false, tag.tags.failed("delete", tag, err)
+	}
+	ctx, cancel := tag.tags.context(context.Background())
+	defer cancel()
+	var removed bool
+	err := tag.tags.transaction(ctx, func(tx *txn) error {
+		var err error
+		removed, err = tag.removeTx(tx)
+		return err
+	})
+	if err := tag.tags.finish("delete", tag, err); err != nil {
+		return false, err
+	}
+	return removed, nil
+}
+
+// DeleteIf removes the value of the tag only if it is equal to the expected
+// value, and reports whether it was removed. The comparison and the removal
+// happen as a single atomic operation, so values that have changed since
+// the caller decided to delete them are preserved. Values are compared
+// using their JSON representation.
+func (tag *Tag) DeleteIf(expected any) (bool, error) {
+	tag.warnDeprecated("delete")
+	if err := tag.tags.checkUndecorated(); err != nil {
+		return false, tag.tags.failed("delete", tag, err)
+	}
+	ctx, cancel := tag.tags.context(context.Background())
+	defer cancel()
+	var removed bool
+	err := tag.tags.transaction(ctx, func(tx *txn) error {
+		want, err := tag.tags.codec.Marshal(expected)
+		if err != nil {
+			return err
+		}
+		raw, exists, err := tag.fetchTx(tx)
+		if err != nil || !exists {
+			return err
+		}
+		var equal bool
+		if err := tx.QueryRowContext(tx.ctx, tagEquals, raw, string(want)).Scan(&equal); err != nil {
+			return err
+		}
+		if !equal {
+			tag.tags.stats.conflict(tag.universe, tag.key)
+			return nil
+		}
+		removed, err = tag.removeTx(tx)
+		return err
+	})
+	if err := tag.tags.finish("delete", tag, err); err != nil {
+		return false, err
+	}
+	return removed, nil
+}
@@ -0,0 +1,170 @@
+package tango
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTagSwap(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	tag := tags.Tag("1234", "5678", "prefix")
+	var old string
+	existed, err := tag.Swap("!", &old)
+	if err != nil {
+		t.Error(err)
+	}
+	if existed {
+		t.Errorf("Expected key not to exist before the swap")
+	}
+	if old != "" {
+		t.Errorf("Expected old value to be untouched, was `%s`", old)
+	}
+
+	existed, err = tag.Swap("?", &old)
+	if err != nil {
+		t.Error(err)
+	}
+	if !existed {
+		t.Errorf("Expected key to exist before the swap")
+	}
+	if old != "!" {
+		t.Errorf("Expected old value to be '!', was `%s`", old)
+	}
+	var result string
+	if _, err := tag.Get(&result); err != nil {
+		t.Error(err)
+	}
+	if result != "?" {
+		t.Errorf("Expected key to resolve to '?', was `%s`", result)
+	}
+}
+
+func TestTagSwapLegacy(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithAlias("pfx", "prefix"))
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', '5678', 'pfx', '"!"')`); err != nil {
+		t.Error(err)
+	}
+	var old string
+	existed, err := tags.Tag("1234", "5678", "prefix").Swap("?", &old)
+	if err != nil {
+		t.Error(err)
+	}
+	if !existed || old != "!" {
+		t.Errorf("Expected old value to be '!', was `%s` (%v)", old, existed)
+	}
+}
+
+func TestTagDeleteExisting(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	tag := tags.Tag("1234", "5678", "prefix")
+	if err := tag.Set("!"); err != nil {
+		t.Error(err)
+	}
+	removed, err := tag.DeleteExisting()
+	if err != nil {
+		t.Error(err)
+	}
+	if !removed {
+		t.Errorf("Expected key to be removed")
+	}
+	removed, err = tag.DeleteExisting()
+	if err != nil {
+		t.Error(err)
+	}
+	if removed {
+		t.Errorf("Expected nothing to be removed")
+	}
+}
+
+func TestTagDeleteIf(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', '5678', 'roles', '[ "admin", "mod" ]')`); err != nil {
+		t.Error(err)
+	}
+	tag := tags.Tag("1234", "5678", "roles")
+	removed, err := tag.DeleteIf([]string{"admin"})
+	if err != nil {
+		t.Error(err)
+	}
+	if removed {
+		t.Errorf("Expected key not to be removed if the value differs")
+	}
+	removed, err = tag.DeleteIf([]string{"admin", "mod"})
+	if err != nil {
+		t.Error(err)
+	}
+	if !removed {
+		t.Errorf("Expected key to be removed if the value matches")
+	}
+	if exists, err := tag.Get(nil); err != nil || exists {
+		t.Errorf("Expected key not to exist (%v, %v)", exists, err)
+	}
+}
+
+func TestTagGetOrSet(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tag := tags.Tag("1234", "5678", "lang")
+
+	var lang string
+	if existed, err := tag.GetOrSet(&lang, "es"); err != nil || existed || lang != "es" {
+		t.Errorf("Expected the fallback to be set, got %s (%v, %v)", lang, existed, err)
+	}
+	if existed, err := tag.GetOrSet(&lang, "en"); err != nil || !existed || lang != "es" {
+		t.Errorf("Expected the first value to be kept, got %s (%v, %v)", lang, existed, err)
+	}
+	if _, err := tag.Get(&lang); err != nil || lang != "es" {
+		t.Errorf("Expected the fallback to be persisted, got %s (%v)", lang, err)
+	}
+}
+
+func TestTagGetOrDefault(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tag := tags.Tag("1234", "5678", "lang")
+
+	var lang string
+	if exists, err := tag.GetOrDefault(&lang, "en"); err != nil || exists || lang != "en" {
+		t.Errorf("Expected the default value, got %s (%v, %v)", lang, exists, err)
+	}
+	if exists, err := tag.Exists(); err != nil || exists {
+		t.Errorf("Expected the default value not to be written (%v, %v)", exists, err)
+	}
+	if err := tag.Set("es"); err != nil {
+		t.Error(err)
+	}
+	if exists, err := tag.GetOrDefault(&lang, "en"); err != nil || !exists || lang != "es" {
+		t.Errorf("Expected the stored value, got %s (%v, %v)", lang, exists, err)
+	}
+	var points int
+	if _, err := tag.GetOrDefault(&points, 0); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expected ErrTypeMismatch, got %v", err)
+	}
+}
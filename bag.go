@@ -0,0 +1,143 @@
+package tango
+
+import (
+	"context"
+	"strings"
+)
+
+var (
+	// The prefix is compared using substr instead of LIKE because LIKE
+	// is case insensitive in SQLite and needs escaping.
+	tagKeysPrefix   = `SELECT key FROM {table} WHERE universe = ? AND entity = ? AND substr(key, 1, length(?)) = ?`
+	tagDeletePrefix = `DELETE FROM {table} WHERE universe = ? AND entity = ? AND substr(key, 1, length(?)) = ?`
+	tagKeysIn       = `SELECT key FROM {table} WHERE universe = ? AND entity = ? AND key IN ({keys})`
+)
+
+// DeletePrefix removes every tag of the tagbag whose key starts with the
+// given prefix using a single statement, and returns the number of tags
+// removed. It is useful to clean up groups of temporary keys, such as every
+// key starting with "tmp:". Keys are compared as they are stored, so
+// aliases are not taken into account.
+func (bag *TagBag) DeletePrefix(prefix string) (int, error) {
+	prefix = bag.tags.keyPrefix + prefix
+	tag := &Tag{universe: bag.universe, entity: bag.entity, key: prefix, name: prefix}
+	if bag.tags.readOnly {
+		return 0, bag.tags.failed("delete", tag, ErrReadOnly)
+	}
+	if err := bag.tags.checkUndecorated(); err != nil {
+		return 0, bag.tags.failed("delete", tag, err)
+	}
+	if err := bag.tags.checkAddress(bag.universe, bag.entity); err != nil {
+		return 0, bag.tags.failed("delete", tag, err)
+	}
+	ctx, done := bag.tags.trace(context.Background(), "delete", tag)
+	defer done()
+	ctx, cancel := bag.tags.context(ctx)
+	defer cancel()
+	var removed int64
+	err := bag.tags.transaction(ctx, func(tx *txn) error {
+		keys, err := bag.keysWithPrefix(tx, prefix)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			tag := &Tag{tags: bag.tags, universe: bag.universe, entity: bag.entity, key: key, name: key}
+			if err := tag.summarize(tx, "", false); err != nil {
+				return err
+			}
+			if err := tag.tombstone(tx); err != nil {
+				return err
+			}
+			tx.written(tag, "", false)
+		}
+		query := bag.tags.sql(tagDeletePrefix)
+		traceStatement(tx.ctx, query, bag.universe, bag.entity, prefix, prefix)
+		result, err := tx.ExecContext(tx.ctx, query, bag.universe, bag.entity, prefix, prefix)
+		if err != nil {
+			return err
+		}
+		removed, err = result.RowsAffected()
+		return err
+	})
+	if err := bag.tags.finish("delete", tag, err); err != nil {
+		return 0, err
+	}
+	return int(removed), nil
+}
+
+// keysWithPrefix returns the keys of the tagbag that start with the given
+// prefix, as part of the given transaction.
+func (bag *TagBag) keysWithPrefix(tx *txn, prefix string) ([]string, error) {
+	rs, err := tx.QueryContext(tx.ctx, bag.tags.sql(tagKeysPrefix), bag.universe, bag.entity, prefix, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	var keys []string
+	for rs.Next() {
+		var key string
+		if err := rs.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rs.Err()
+}
+
+// HasMany reports which of the given keys are set for the entity using a
+// single query. Every key is present in the returned map. Legacy aliases
+// are taken into account, but loaders are not run.
+func (bag *TagBag) HasMany(keys []string) (map[string]bool, error) {
+	tag := &Tag{universe: bag.universe, entity: bag.entity}
+	if err := bag.tags.checkUndecorated(); err != nil {
+		return nil, bag.tags.failed("get", tag, err)
+	}
+	if err := bag.tags.checkAddress(bag.universe, bag.entity); err != nil {
+		return nil, bag.tags.failed("get", tag, err)
+	}
+	ctx, done := bag.tags.trace(context.Background(), "get", tag)
+	defer done()
+	ctx, cancel := bag.tags.context(ctx)
+	defer cancel()
+	result, err := bag.hasMany(ctx, keys)
+	return result, bag.tags.finish("get", tag, err)
+}
+
+func (bag *TagBag) hasMany(ctx context.Context, keys []string) (map[string]bool, error) {
+	result := make(map[string]bool, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	// Every key is looked up along with its legacy aliases.
+	lookup := make(map[string][]string, len(keys))
+	args := []any{bag.universe, bag.entity}
+	for _, name := range keys {
+		result[name] = false
+		tag := bag.Tag(name)
+		for _, key := range append([]string{tag.key}, bag.tags.legacy[tag.key]...) {
+			if _, ok := lookup[key]; !ok {
+				args = append(args, key)
+			}
+			lookup[key] = append(lookup[key], name)
+		}
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)-2), ", ")
+	query := strings.Replace(bag.tags.sql(tagKeysIn), "{keys}", placeholders, 1)
+	traceStatement(ctx, query, args...)
+	rs, err := bag.tags.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		var key string
+		if err := rs.Scan(&key); err != nil {
+			return nil, err
+		}
+		for _, name := range lookup[key] {
+			result[name] = true
+		}
+	}
+	return result, rs.Err()
+}
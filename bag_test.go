@@ -0,0 +1,63 @@
+package tango
+
+import "testing"
+
+func TestTagBagDeletePrefix(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', '5678', 'tmp:a', '1'),
+		('1234', '5678', 'tmp:b', '2'),
+		('1234', '5678', 'TMP:c', '3'),
+		('1234', '5678', 'tmpd', '4'),
+		('1234', '9999', 'tmp:a', '5')`); err != nil {
+		t.Error(err)
+	}
+	removed, err := tags.TagBag("1234", "5678").DeletePrefix("tmp:")
+	if err != nil {
+		t.Error(err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 tags to be removed, were %d", removed)
+	}
+	list, err := tags.TagBag("1234", "5678").Tags()
+	if err != nil {
+		t.Error(err)
+	}
+	if len(list) != 2 {
+		t.Errorf("Expected TMP:c and tmpd to remain, was %v", list)
+	}
+	list, err = tags.TagBag("1234", "9999").Tags()
+	if err != nil {
+		t.Error(err)
+	}
+	if len(list) != 1 {
+		t.Errorf("Expected other entities not to be affected, was %v", list)
+	}
+}
+
+func TestTagBagHasMany(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithAlias("old_beta", "beta"))
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', '5678', 'alpha', 'true'),
+		('1234', '5678', 'old_beta', 'false')`); err != nil {
+		t.Error(err)
+	}
+	has, err := tags.TagBag("1234", "5678").HasMany([]string{"alpha", "beta", "gamma", "old_beta"})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(has) != 4 || !has["alpha"] || !has["beta"] || has["gamma"] || !has["old_beta"] {
+		t.Errorf("Expected alpha, beta and old_beta to be set, was %v", has)
+	}
+}
@@ -0,0 +1,143 @@
+package tango
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+var (
+	bloomQuery = `SELECT entity, key FROM {table} WHERE universe = ?`
+)
+
+// A bloomFilters keeps a bloom filter of the entity and key pairs stored in
+// every universe, so that reads of tags that are definitely not set can be
+// answered without querying the database. A nil bloomFilters filters
+// nothing.
+type bloomFilters struct {
+	mu        sync.Mutex
+	bits      int
+	hashes    int
+	universes map[string]*bloom
+}
+
+// A bloom is the filter of a single universe. Bits are set by writes even
+// before the filter is loaded, so that writes made while the filter is
+// being loaded are not lost.
+type bloom struct {
+	load   sync.Mutex
+	mu     sync.Mutex
+	loaded bool
+	bits   []uint64
+}
+
+func newBloomFilters(expected int, fpRate float64) *bloomFilters {
+	if expected <= 0 {
+		expected = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	bits := math.Ceil(-float64(expected) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	hashes := int(math.Round(bits / float64(expected) * math.Ln2))
+	if hashes < 1 {
+		hashes = 1
+	}
+	return &bloomFilters{bits: int(bits), hashes: hashes, universes: make(map[string]*bloom)}
+}
+
+// filter returns the filter of the given universe, creating it if needed.
+func (b *bloomFilters) filter(universe string) *bloom {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	f, ok := b.universes[universe]
+	if !ok {
+		f = &bloom{bits: make([]uint64, (b.bits+63)/64)}
+		b.universes[universe] = f
+	}
+	return f
+}
+
+// positions returns the bits used by the given entity and key, using the
+// double hashing of a 64 bit FNV hash.
+func (b *bloomFilters) positions(entity, key string) []int {
+	h := fnv.New64a()
+	h.Write([]byte(entity))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	h1, h2 := uint32(sum), uint32(sum>>32)
+	positions := make([]int, b.hashes)
+	for i := range positions {
+		positions[i] = int((uint64(h1) + uint64(i)*uint64(h2)) % uint64(b.bits))
+	}
+	return positions
+}
+
+// add records that the given key of an entity is stored.
+func (b *bloomFilters) add(universe, entity, key string) {
+	if b == nil {
+		return
+	}
+	f := b.filter(universe)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range b.positions(entity, key) {
+		f.bits[p/64] |= 1 << (p % 64)
+	}
+}
+
+// mayContain returns false if the given key of an entity is definitely not
+// stored. The filter of the universe is loaded from the database on the
+// first lookup; if loading it fails, the key may be stored.
+func (b *bloomFilters) mayContain(ctx context.Context, tags *Tags, universe, entity, key string) bool {
+	if b == nil {
+		return true
+	}
+	f := b.filter(universe)
+	if err := b.ensureLoaded(ctx, tags, universe, f); err != nil {
+		return true
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range b.positions(entity, key) {
+		if f.bits[p/64]&(1<<(p%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ensureLoaded adds every key stored in the universe to its filter, unless
+// the filter was already loaded.
+func (b *bloomFilters) ensureLoaded(ctx context.Context, tags *Tags, universe string, f *bloom) error {
+	f.load.Lock()
+	defer f.load.Unlock()
+	f.mu.Lock()
+	loaded := f.loaded
+	f.mu.Unlock()
+	if loaded {
+		return nil
+	}
+
+	rs, err := tags.db.QueryContext(ctx, tags.sql(bloomQuery), universe)
+	if err != nil {
+		return err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		var entity, key string
+		if err := rs.Scan(&entity, &key); err != nil {
+			return err
+		}
+		b.add(universe, entity, key)
+	}
+	if err := rs.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.loaded = true
+	f.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,62 @@
+package tango
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBloomFilterSkipsMissingKeys(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', '5678', 'string', '"hello"')`); err != nil {
+		t.Error(err)
+	}
+	tags := NewTagsEngine(db, WithBloomFilter(100, 0.001))
+
+	// Keys stored before the filter is loaded are found.
+	var result string
+	if exists, err := tags.Tag("1234", "5678", "string").Get(&result); err != nil || !exists || result != "hello" {
+		t.Errorf("Expected string to be hello, was %s (%v, %v)", result, exists, err)
+	}
+
+	// Keys inserted behind the back of the engine are not.
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', '5678', 'hidden', '"bye"')`); err != nil {
+		t.Error(err)
+	}
+	if exists, err := tags.Tag("1234", "5678", "hidden").Get(&result); err != nil || exists {
+		t.Errorf("Expected hidden to be filtered out (%v, %v)", exists, err)
+	}
+
+	// But keys written through the engine are.
+	if err := tags.Tag("1234", "5678", "number").Set(33); err != nil {
+		t.Error(err)
+	}
+	var number int
+	if exists, err := tags.Tag("1234", "5678", "number").Get(&number); err != nil || !exists || number != 33 {
+		t.Errorf("Expected number to be 33, was %d (%v, %v)", number, exists, err)
+	}
+}
+
+func TestBloomFilterBulkUpsert(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithBloomFilter(100, 0.01))
+
+	var result string
+	if exists, err := tags.Tag("1234", "5678", "string").Get(&result); err != nil || exists {
+		t.Errorf("Expected string not to exist (%v, %v)", exists, err)
+	}
+	records := []Record{{Universe: "1234", Entity: "5678", Key: "string", Value: []byte(`"hello"`)}}
+	if _, err := tags.BulkUpsert(context.Background(), records, BulkOptions{}); err != nil {
+		t.Error(err)
+	}
+	if exists, err := tags.Tag("1234", "5678", "string").Get(&result); err != nil || !exists || result != "hello" {
+		t.Errorf("Expected string to be hello, was %s (%v, %v)", result, exists, err)
+	}
+}
@@ -0,0 +1,248 @@
+package tango
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+var (
+	deleteUniverseChunk = `DELETE FROM {table} WHERE id IN (SELECT id FROM {table} WHERE universe = ? LIMIT ?)`
+	deleteSummaries     = `DELETE FROM {table}_summaries WHERE universe = ?`
+	countUniverse       = `SELECT COUNT(*) FROM {table} WHERE universe = ?`
+)
+
+// defaultChunkSize is the number of records written per transaction by the
+// bulk operations when the options do not say otherwise.
+const defaultChunkSize = 1000
+
+// BulkOptions tunes the bulk operations of the engine.
+type BulkOptions struct {
+	// ChunkSize is the number of records written as part of a single
+	// transaction. It defaults to 1000 records.
+	ChunkSize int
+
+	// Progress, if set, receives a report after every chunk.
+	Progress Progress
+
+	// Pause is the time to wait between chunks, which leaves room for
+	// other operations over the store while a long operation runs.
+	Pause time.Duration
+
+	// Checkpoint, if set, is the name of a checkpoint where bulk upserts
+	// record how many records they have written, as part of the same
+	// transaction that writes every chunk. Calling the operation again
+	// with the same checkpoint skips the records already written, so an
+	// interrupted operation can be resumed by running it again with the
+	// same records, in which case the number of records returned includes
+	// those written by previous runs. The checkpoint is cleared when the
+	// operation finishes. It requires the checkpoints table.
+	Checkpoint string
+
+	// DryRun makes the operations that delete tags report what they would
+	// delete without deleting anything.
+	DryRun bool
+}
+
+// chunkSize returns the number of records to process per chunk.
+func (opts BulkOptions) chunkSize() int {
+	if opts.ChunkSize <= 0 {
+		return defaultChunkSize
+	}
+	return opts.ChunkSize
+}
+
+// BulkUpsert writes the given records into the store, in chunks of records
+// that are written as part of a single transaction each, which is much
+// faster than setting every tag separately. Values are written as given,
+// so hooks such as validators are not called and summaries are not
+// updated. If writing a chunk fails or the context is cancelled, the chunks
+// written before are kept, so the store remains consistent. It returns the
+// number of records written, which can be used to resume the operation by
+// calling it again with the records that were not written.
+func (tags *Tags) BulkUpsert(ctx context.Context, records []Record, opts BulkOptions) (int, error) {
+	if err := tags.checkUnscoped(); err != nil {
+		return 0, err
+	}
+	i := 0
+	return tags.bulkUpsert(ctx, func() (Record, bool) {
+		if i == len(records) {
+			return Record{}, false
+		}
+		i++
+		return records[i-1], true
+	}, len(records), opts)
+}
+
+// BulkUpsertFrom works like BulkUpsert, but it writes the records received
+// from the channel until it is closed, so that records can be streamed
+// without keeping them in memory.
+func (tags *Tags) BulkUpsertFrom(ctx context.Context, records <-chan Record, opts BulkOptions) (int, error) {
+	if err := tags.checkUnscoped(); err != nil {
+		return 0, err
+	}
+	return tags.bulkUpsert(ctx, func() (Record, bool) {
+		select {
+		case record, ok := <-records:
+			return record, ok
+		case <-ctx.Done():
+			return Record{}, false
+		}
+	}, 0, opts)
+}
+
+// bulkUpsert writes the records returned by next until it reports that
+// there are no more records. The total number of records is only used to
+// report progress, and it may be zero if it is unknown.
+func (tags *Tags) bulkUpsert(ctx context.Context, next func() (Record, bool), total int, opts BulkOptions) (int, error) {
+	tracker := tags.track(opts.Progress, "bulk upsert", total)
+	size := opts.chunkSize()
+	written, err := tags.resumeFrom(ctx, opts.Checkpoint)
+	if err != nil {
+		return 0, err
+	}
+	for skip := 0; skip < written; skip++ {
+		if _, ok := next(); !ok {
+			break
+		}
+	}
+	for done := false; !done; {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		chunk := make([]Record, 0, size)
+		for len(chunk) < size {
+			record, ok := next()
+			if !ok {
+				done = true
+				break
+			}
+			chunk = append(chunk, record)
+		}
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		if len(chunk) == 0 {
+			break
+		}
+		if err := tags.upsertChunk(ctx, chunk, opts.Checkpoint, written+len(chunk)); err != nil {
+			return written, err
+		}
+		written += len(chunk)
+		tracker.report(written)
+		if !done {
+			if err := opts.pause(ctx); err != nil {
+				return written, err
+			}
+		}
+	}
+	if opts.Checkpoint != "" {
+		if err := tags.ClearCheckpoint(ctx, opts.Checkpoint); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// pause waits before processing the next chunk, if the options ask to. It
+// returns the error of the context if it is done while waiting.
+func (opts BulkOptions) pause(ctx context.Context) error {
+	if opts.Pause <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(opts.Pause)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// upsertChunk writes the given records as part of a single transaction,
+// updating the given checkpoint, if any, with the number of records written.
+func (tags *Tags) upsertChunk(ctx context.Context, chunk []Record, checkpoint string, written int) error {
+	return tags.transaction(ctx, func(tx *txn) error {
+		stmt, err := tx.PrepareContext(ctx, tags.sql(tagUpsert))
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, record := range chunk {
+			if err := tags.checkIDs(record.Universe, record.Entity, record.Key); err != nil {
+				return err
+			}
+			if _, err := stmt.ExecContext(ctx, record.Universe, record.Entity, record.Key, string(record.Value)); err != nil {
+				return err
+			}
+			tag := &Tag{tags: tags, universe: record.Universe, entity: record.Entity, key: record.Key, name: record.Key}
+			tx.written(tag, string(record.Value), true)
+		}
+		if checkpoint != "" {
+			return tags.saveCheckpoint(ctx, tx, checkpoint, strconv.Itoa(written))
+		}
+		return nil
+	})
+}
+
+// DeleteUniverse removes every tag of the given universe, along with its
+// summaries. Tags are removed in chunks, each one as part of a separate
+// transaction, so that purging a large universe does not lock the store
+// for a long time. The context is checked between chunks; if it is
+// cancelled, the tags removed so far stay removed and calling it again
+// resumes the purge. It returns the number of tags removed or, if the
+// options ask for a dry run, the number of tags that would be removed.
+func (tags *Tags) DeleteUniverse(ctx context.Context, universe string, opts BulkOptions) (int, error) {
+	if err := tags.checkUnscoped(); err != nil {
+		return 0, err
+	}
+	if opts.DryRun {
+		return tags.countUniverse(ctx, universe)
+	}
+	var tracker *progressTracker
+	if opts.Progress != nil {
+		total, err := tags.countUniverse(ctx, universe)
+		if err != nil {
+			return 0, err
+		}
+		tracker = tags.track(opts.Progress, "delete universe", total)
+	}
+	size := opts.chunkSize()
+	removed := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+		result, err := tags.db.ExecContext(ctx, tags.sql(deleteUniverseChunk), universe, size)
+		if err != nil {
+			return removed, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return removed, err
+		}
+		removed += int(affected)
+		tags.cache.clear()
+		tracker.report(removed)
+		if affected < int64(size) {
+			break
+		}
+		if err := opts.pause(ctx); err != nil {
+			return removed, err
+		}
+	}
+	if len(tags.summaries) > 0 {
+		if _, err := tags.db.ExecContext(ctx, tags.sql(deleteSummaries), universe); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// countUniverse returns the number of tags of the given universe.
+func (tags *Tags) countUniverse(ctx context.Context, universe string) (int, error) {
+	var count int
+	err := tags.db.QueryRowContext(ctx, tags.sql(countUniverse), universe).Scan(&count)
+	return count, err
+}
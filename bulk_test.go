@@ -0,0 +1,179 @@
+package tango
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBulkUpsert(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	var records []Record
+	for i := 0; i < 25; i++ {
+		records = append(records, Record{Universe: "1234", Entity: strconv.Itoa(i), Key: "points", Value: json.RawMessage(strconv.Itoa(i))})
+	}
+	var progress []ProgressInfo
+	written, err := tags.BulkUpsert(context.Background(), records, BulkOptions{ChunkSize: 10, Progress: ProgressFunc(func(info ProgressInfo) {
+		progress = append(progress, info)
+	})})
+	if err != nil {
+		t.Error(err)
+	}
+	if written != 25 {
+		t.Errorf("Expected 25 records to be written, were %d", written)
+	}
+	if len(progress) != 3 || progress[0].Processed != 10 || progress[1].Processed != 20 || progress[2].Processed != 25 {
+		t.Errorf("Unexpected progress %+v", progress)
+	}
+	if progress[0].Op != "bulk upsert" || progress[0].Total != 25 || progress[2].ETA != 0 {
+		t.Errorf("Unexpected progress %+v", progress)
+	}
+	var points int
+	if _, err := tags.Tag("1234", "24", "points").Get(&points); err != nil || points != 24 {
+		t.Errorf("Expected points to be 24, was %d (%v)", points, err)
+	}
+}
+
+func TestBulkUpsertFrom(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	records := make(chan Record)
+	go func() {
+		defer close(records)
+		for i := 0; i < 5; i++ {
+			records <- Record{Universe: "1234", Entity: "5678", Key: "k" + strconv.Itoa(i), Value: json.RawMessage(`true`)}
+		}
+	}()
+	written, err := tags.BulkUpsertFrom(context.Background(), records, BulkOptions{ChunkSize: 2})
+	if err != nil {
+		t.Error(err)
+	}
+	if written != 5 {
+		t.Errorf("Expected 5 records to be written, were %d", written)
+	}
+	list, err := tags.TagBag("1234", "5678").Tags()
+	if err != nil {
+		t.Error(err)
+	}
+	if len(list) != 5 {
+		t.Errorf("Expected 5 tags, got %v", list)
+	}
+}
+
+func TestDeleteUniverse(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	var records []Record
+	for i := 0; i < 25; i++ {
+		records = append(records, Record{Universe: "1234", Entity: strconv.Itoa(i), Key: "points", Value: json.RawMessage(`1`)})
+	}
+	records = append(records, Record{Universe: "4321", Entity: "5678", Key: "points", Value: json.RawMessage(`1`)})
+	if _, err := tags.BulkUpsert(context.Background(), records, BulkOptions{}); err != nil {
+		t.Error(err)
+	}
+
+	removed, err := tags.DeleteUniverse(context.Background(), "1234", BulkOptions{DryRun: true})
+	if err != nil || removed != 25 {
+		t.Errorf("Expected 25 tags to be removed in a dry run, were %d (%v)", removed, err)
+	}
+	var points int
+	if exists, err := tags.Tag("1234", "0", "points").Get(&points); err != nil || !exists {
+		t.Errorf("Expected a dry run to keep the tags (%v, %v)", exists, err)
+	}
+
+	var progress []ProgressInfo
+	removed, err = tags.DeleteUniverse(context.Background(), "1234", BulkOptions{ChunkSize: 10, Progress: ProgressFunc(func(info ProgressInfo) {
+		progress = append(progress, info)
+	})})
+	if err != nil {
+		t.Error(err)
+	}
+	if removed != 25 {
+		t.Errorf("Expected 25 tags to be removed, were %d", removed)
+	}
+	if len(progress) != 3 || progress[2].Processed != 25 || progress[2].Total != 25 {
+		t.Errorf("Unexpected progress %v", progress)
+	}
+	if exists, err := tags.Tag("1234", "0", "points").Get(&points); err != nil || exists {
+		t.Errorf("Expected tag to be removed (%v, %v)", exists, err)
+	}
+	if exists, err := tags.Tag("4321", "5678", "points").Get(&points); err != nil || !exists {
+		t.Errorf("Expected other universes not to be affected (%v, %v)", exists, err)
+	}
+}
+
+func TestBulkUpsertCancel(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	var records []Record
+	for i := 0; i < 25; i++ {
+		records = append(records, Record{Universe: "1234", Entity: strconv.Itoa(i), Key: "points", Value: json.RawMessage(`1`)})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	written, err := tags.BulkUpsert(ctx, records, BulkOptions{ChunkSize: 10, Progress: ProgressFunc(func(ProgressInfo) {
+		cancel()
+	})})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the operation to be cancelled, got %v", err)
+	}
+	if written != 10 {
+		t.Errorf("Expected the first chunk to be written, were %d", written)
+	}
+
+	// The operation can be resumed from the records not written.
+	written, err = tags.BulkUpsert(context.Background(), records[written:], BulkOptions{ChunkSize: 10})
+	if err != nil || written != 15 {
+		t.Errorf("Expected the rest of the records to be written, were %d (%v)", written, err)
+	}
+}
+
+func TestBulkPauseCancel(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	var records []Record
+	for i := 0; i < 25; i++ {
+		records = append(records, Record{Universe: "1234", Entity: strconv.Itoa(i), Key: "points", Value: json.RawMessage(`1`)})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	written, err := tags.BulkUpsert(ctx, records, BulkOptions{ChunkSize: 10, Pause: time.Hour})
+	if !errors.Is(err, context.DeadlineExceeded) || written != 10 {
+		t.Errorf("Expected the pause to be interrupted after the first chunk, written %d (%v)", written, err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected the pause to end with the context, took %s", elapsed)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	removed, err := tags.DeleteUniverse(ctx, "1234", BulkOptions{ChunkSize: 5, Pause: time.Hour})
+	if !errors.Is(err, context.DeadlineExceeded) || removed != 5 {
+		t.Errorf("Expected the pause to be interrupted after the first chunk, removed %d (%v)", removed, err)
+	}
+}
@@ -0,0 +1,113 @@
+package tango
+
+import (
+	"sync"
+	"time"
+)
+
+// A cache keeps the values read from the database in memory for a limited
+// amount of time. Values are evicted from the cache when they are written
+// through the same engine, but writes made by other processes will not be
+// seen until the cached values expire. A nil cache caches nothing.
+type cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[cacheKey]cacheEntry
+
+	// onEvict is called with the universe of every value dropped from the
+	// cache because it expired or to make room for another value.
+	onEvict func(universe string)
+}
+
+type cacheKey struct {
+	universe, entity, key string
+}
+
+type cacheEntry struct {
+	raw     string
+	exists  bool
+	expires time.Time
+}
+
+func newCache(ttl time.Duration, size int) *cache {
+	return &cache{ttl: ttl, size: size, entries: make(map[cacheKey]cacheEntry)}
+}
+
+// get returns the cached value for a tag, and whether it was cached.
+func (c *cache) get(universe, entity, key string) (string, bool, bool) {
+	if c == nil {
+		return "", false, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := cacheKey{universe, entity, key}
+	entry, ok := c.entries[k]
+	if !ok {
+		return "", false, false
+	}
+	if time.Now().After(entry.expires) {
+		c.drop(k)
+		return "", false, false
+	}
+	return entry.raw, entry.exists, true
+}
+
+// put stores the value of a tag, making room for it if the cache is full.
+// The value expires after the given ttl or, if zero, the ttl of the cache.
+func (c *cache) put(universe, entity, key, raw string, exists bool, ttl time.Duration) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if c.size > 0 && len(c.entries) >= c.size {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				c.drop(k)
+			}
+		}
+		// If nothing expired, drop any entry. Map iteration order is
+		// random enough to be used as a random eviction policy.
+		for k := range c.entries {
+			if len(c.entries) < c.size {
+				break
+			}
+			c.drop(k)
+		}
+	}
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+	c.entries[cacheKey{universe, entity, key}] = cacheEntry{raw: raw, exists: exists, expires: now.Add(ttl)}
+}
+
+// drop evicts a value that was not written through the engine. The mutex
+// must be held by the caller.
+func (c *cache) drop(k cacheKey) {
+	delete(c.entries, k)
+	if c.onEvict != nil {
+		c.onEvict(k.universe)
+	}
+}
+
+// clear removes every value from the cache.
+func (c *cache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[cacheKey]cacheEntry)
+}
+
+// evict removes the value of a tag from the cache.
+func (c *cache) evict(universe, entity, key string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, cacheKey{universe, entity, key})
+}
@@ -0,0 +1,245 @@
+package tango
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	tombstoneUpsert = `
+	INSERT INTO {table}_tombstones (universe, entity, key, deleted_at) VALUES(?, ?, ?, ?)
+	ON CONFLICT(universe, entity, key) DO UPDATE SET deleted_at=excluded.deleted_at
+`
+	tombstonePrune = `DELETE FROM {table}_tombstones WHERE deleted_at < ?`
+
+	changedTags = `SELECT universe, entity, key, value, updated_at FROM {table}
+	WHERE updated_at > ? ORDER BY updated_at`
+	changedTombstones = `SELECT universe, entity, key, deleted_at FROM {table}_tombstones t
+	WHERE deleted_at > ? AND NOT EXISTS (
+		SELECT 1 FROM {table} WHERE universe = t.universe AND entity = t.entity AND key = t.key
+	) ORDER BY deleted_at`
+)
+
+// A Change is a line of an incremental export, which either sets the value
+// of a tag or, if Deleted is set, removes it.
+type Change struct {
+	Universe string          `json:"universe"`
+	Entity   string          `json:"entity"`
+	Key      string          `json:"key"`
+	Value    json.RawMessage `json:"value,omitempty"`
+	Deleted  bool            `json:"deleted,omitempty"`
+	At       time.Time       `json:"at"`
+}
+
+// tombstone records that the tag has been deleted, if the engine keeps
+// tombstones.
+func (tag *Tag) tombstone(tx *txn) error {
+	if !tag.tags.tombstones {
+		return nil
+	}
+	_, err := tx.ExecContext(tx.ctx, tag.tags.sql(tombstoneUpsert), tag.universe, tag.entity, tag.key, tx.now)
+	return err
+}
+
+// ExportChangedSince writes into the writer the tags written after the given
+// time, followed by the tags deleted after that time, as JSON encoded
+// Change values, one per line. It returns the number of changes written.
+// The engine must be configured using WithTracking, and deletions are only
+// exported if it is configured using WithTombstones too. Writes that do not
+// record their time, such as bulk upserts and restores, are not exported.
+// To chain incremental exports, take the time before calling it and use it
+// as the time of the next export.
+func (tags *Tags) ExportChangedSince(ctx context.Context, since time.Time, w io.Writer) (int, error) {
+	if err := tags.checkUnscoped(); err != nil {
+		return 0, err
+	}
+	if !tags.tracking {
+		return 0, ErrTrackingDisabled
+	}
+	enc := json.NewEncoder(w)
+	written := 0
+	err := tags.changesSince(ctx, since, func(change Change) error {
+		if err := enc.Encode(&change); err != nil {
+			return err
+		}
+		written++
+		return nil
+	})
+	return written, err
+}
+
+// changesSince calls fn with the tags written after the given time, and
+// then with the tags deleted after that time, if the engine keeps
+// tombstones.
+func (tags *Tags) changesSince(ctx context.Context, since time.Time, fn func(Change) error) error {
+	rs, err := tags.db.QueryContext(ctx, tags.sql(changedTags), since.UTC())
+	if err != nil {
+		return err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		var change Change
+		var value string
+		var at sql.NullTime
+		if err := rs.Scan(&change.Universe, &change.Entity, &change.Key, &value, &at); err != nil {
+			return err
+		}
+		change.Value, change.At = json.RawMessage(value), at.Time
+		if err := fn(change); err != nil {
+			return err
+		}
+	}
+	if err := rs.Err(); err != nil {
+		return err
+	}
+	rs.Close()
+
+	if !tags.tombstones {
+		return nil
+	}
+	rs, err = tags.db.QueryContext(ctx, tags.sql(changedTombstones), since.UTC())
+	if err != nil {
+		return err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		change := Change{Deleted: true}
+		if err := rs.Scan(&change.Universe, &change.Entity, &change.Key, &change.At); err != nil {
+			return err
+		}
+		if err := fn(change); err != nil {
+			return err
+		}
+	}
+	return rs.Err()
+}
+
+// ApplyChanges reads the changes written by ExportChangedSince from the
+// reader and applies them as part of a single transaction, upserting the
+// tags that were written and deleting the tags that were deleted. As with
+// Restore, hooks are not called and summaries are not updated.
+func (tags *Tags) ApplyChanges(ctx context.Context, r io.Reader) (ImportSummary, error) {
+	if err := tags.checkUnscoped(); err != nil {
+		return ImportSummary{}, err
+	}
+	dec := json.NewDecoder(r)
+	var summary ImportSummary
+	err := tags.transaction(ctx, func(tx *txn) error {
+		im, err := tags.newImporter(tx, ImportOptions{})
+		if err != nil {
+			return err
+		}
+		defer im.close()
+		defer func() { summary = im.summary }()
+		for {
+			var change Change
+			if err := dec.Decode(&change); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if change.Deleted {
+				err = im.delete(change.Universe, change.Entity, change.Key)
+			} else {
+				err = im.write(change.Universe, change.Entity, change.Key, change.Value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	})
+	if err != nil {
+		return ImportSummary{}, err
+	}
+	tags.cache.clear()
+	return summary, nil
+}
+
+// PruneTombstones removes the tombstones of the tags deleted before the
+// given time, which are no longer needed once every incremental export
+// covering that time has been made. It returns the number of tombstones
+// removed.
+func (tags *Tags) PruneTombstones(ctx context.Context, before time.Time) (int, error) {
+	if err := tags.checkUnscoped(); err != nil {
+		return 0, err
+	}
+	result, err := tags.db.ExecContext(ctx, tags.sql(tombstonePrune), before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// RestoreToTime reconstructs the state of a universe as it was at the given
+// time into the target universe, which should be a new universe so that
+// the current state is kept for comparison. The state is rebuilt from a
+// snapshot taken before that time and the incremental exports made after
+// it using ExportChangedSince, given in the order they were made. Changes
+// made after the given time are ignored. Since exports only hold the last
+// value of every tag, the resolution of the result is the interval between
+// exports: a tag written again after the given time but before the next
+// export keeps the value it had in the previous export. There is no event
+// log holding every write, so finer restores are not possible.
+func (tags *Tags) RestoreToTime(ctx context.Context, universe, target string, at time.Time, snapshot io.Reader, changes []io.Reader, opts ImportOptions) (ImportSummary, error) {
+	if err := tags.checkUnscoped(); err != nil {
+		return ImportSummary{}, err
+	}
+	state := make(map[[2]string]json.RawMessage)
+	header, err := readSnapshot(snapshot, opts, func(record *Record) error {
+		if record.Universe == universe {
+			state[[2]string{record.Entity, record.Key}] = record.Value
+		}
+		return nil
+	})
+	if err != nil {
+		return ImportSummary{}, err
+	}
+	if header.CreatedAt.After(at) {
+		return ImportSummary{}, fmt.Errorf("%w: snapshot was taken after %s", ErrInvalidSnapshot, at)
+	}
+	for _, r := range changes {
+		dec := json.NewDecoder(r)
+		for {
+			var change Change
+			if err := dec.Decode(&change); err == io.EOF {
+				break
+			} else if err != nil {
+				return ImportSummary{}, err
+			}
+			if change.Universe != universe || change.At.After(at) {
+				continue
+			}
+			if change.Deleted {
+				delete(state, [2]string{change.Entity, change.Key})
+			} else {
+				state[[2]string{change.Entity, change.Key}] = change.Value
+			}
+		}
+	}
+
+	var summary ImportSummary
+	err = tags.transaction(ctx, func(tx *txn) error {
+		im, err := tags.newImporter(tx, opts)
+		if err != nil {
+			return err
+		}
+		defer im.close()
+		defer func() { summary = im.summary }()
+		for address, value := range state {
+			if err := im.write(target, address[0], address[1], value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return ImportSummary{}, err
+	}
+	tags.cache.clear()
+	return summary, nil
+}
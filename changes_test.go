@@ -0,0 +1,153 @@
+package tango
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestExportChangedSince(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	tags := NewTagsEngine(db, WithTracking(), WithTombstones())
+
+	for _, key := range []string{"old", "deleted", "recreated"} {
+		if err := tags.Tag("1234", "5678", key).Set(1); err != nil {
+			t.Error(err)
+		}
+	}
+	since := time.Now()
+	if err := tags.Tag("1234", "5678", "new").Set(2); err != nil {
+		t.Error(err)
+	}
+	for _, key := range []string{"deleted", "recreated", "missing"} {
+		if err := tags.Tag("1234", "5678", key).Delete(); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := tags.Tag("1234", "5678", "recreated").Set(3); err != nil {
+		t.Error(err)
+	}
+
+	var buf bytes.Buffer
+	written, err := tags.ExportChangedSince(context.Background(), since, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != 3 {
+		t.Errorf("Expected 3 changes, were %d:\n%s", written, buf.String())
+	}
+
+	if removed, err := tags.PruneTombstones(context.Background(), time.Now()); err != nil || removed != 2 {
+		t.Errorf("Expected 2 tombstones to be pruned, were %d (%v)", removed, err)
+	}
+	db.Close()
+
+	// Apply the changes into a replica that has the state before them.
+	replica, _, err := prepareTagEngine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replica.Close()
+	if _, err := replica.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', '5678', 'old', '1'),
+		('1234', '5678', 'deleted', '1'),
+		('1234', '5678', 'recreated', '1')`); err != nil {
+		t.Error(err)
+	}
+	summary, err := NewTagsEngine(replica).ApplyChanges(context.Background(), &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary != (ImportSummary{Inserted: 1, Overwritten: 1, Deleted: 1}) {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+	rs, err := replica.Query(`SELECT key, value FROM tags ORDER BY key`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Close()
+	var state []string
+	for rs.Next() {
+		var key, value string
+		rs.Scan(&key, &value)
+		state = append(state, key+"="+value)
+	}
+	if len(state) != 3 || state[0] != "new=2" || state[1] != "old=1" || state[2] != "recreated=3" {
+		t.Errorf("Unexpected replica state %v", state)
+	}
+}
+
+func TestExportChangedSinceDisabled(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	var buf bytes.Buffer
+	if _, err := tags.ExportChangedSince(context.Background(), time.Now(), &buf); !errors.Is(err, ErrTrackingDisabled) {
+		t.Errorf("Expected ErrTrackingDisabled, got %v", err)
+	}
+}
+
+func TestRestoreToTime(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithTracking(), WithTombstones())
+
+	if err := tags.Tag("1234", "5678", "prefix").Set("!"); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "5678", "locale").Set("es"); err != nil {
+		t.Error(err)
+	}
+	var snapshot bytes.Buffer
+	since := time.Now()
+	if err := tags.Snapshot(context.Background(), &snapshot, SnapshotOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// First incremental export, before the dispute.
+	if err := tags.Tag("1234", "5678", "prefix").Set("?"); err != nil {
+		t.Error(err)
+	}
+	var first bytes.Buffer
+	next := time.Now()
+	if _, err := tags.ExportChangedSince(context.Background(), since, &first); err != nil {
+		t.Error(err)
+	}
+	dispute := time.Now()
+
+	// Second incremental export, after the dispute.
+	if err := tags.Tag("1234", "5678", "locale").Delete(); err != nil {
+		t.Error(err)
+	}
+	var second bytes.Buffer
+	if _, err := tags.ExportChangedSince(context.Background(), next, &second); err != nil {
+		t.Error(err)
+	}
+
+	summary, err := tags.RestoreToTime(context.Background(), "1234", "1234@dispute", dispute, &snapshot, []io.Reader{&first, &second}, ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Inserted != 2 {
+		t.Errorf("Expected 2 tags to be restored, was %+v", summary)
+	}
+	var prefix, locale string
+	if _, err := tags.Tag("1234@dispute", "5678", "prefix").Get(&prefix); err != nil || prefix != "?" {
+		t.Errorf("Expected prefix to be ?, was %s (%v)", prefix, err)
+	}
+	if _, err := tags.Tag("1234@dispute", "5678", "locale").Get(&locale); err != nil || locale != "es" {
+		t.Errorf("Expected locale to be es, was %s (%v)", locale, err)
+	}
+}
@@ -0,0 +1,69 @@
+package tango
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var (
+	checkpointQuery  = `SELECT cursor FROM {table}_checkpoints WHERE name = ?`
+	checkpointUpsert = `INSERT INTO {table}_checkpoints(name, cursor, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET cursor = excluded.cursor, updated_at = excluded.updated_at`
+	checkpointDelete = `DELETE FROM {table}_checkpoints WHERE name = ?`
+)
+
+// Checkpoint returns the cursor stored for the checkpoint with the given
+// name, and whether the checkpoint exists. Checkpoints let long operations
+// continue where they left off after a restart. They require the
+// checkpoints table.
+func (tags *Tags) Checkpoint(ctx context.Context, name string) (string, bool, error) {
+	ctx, cancel := tags.context(ctx)
+	defer cancel()
+	return tags.checkpoint(ctx, tags.db, name)
+}
+
+// SaveCheckpoint stores the cursor of the checkpoint with the given name.
+func (tags *Tags) SaveCheckpoint(ctx context.Context, name, cursor string) error {
+	ctx, cancel := tags.context(ctx)
+	defer cancel()
+	return tags.saveCheckpoint(ctx, tags.db, name, cursor)
+}
+
+// ClearCheckpoint removes the checkpoint with the given name, usually once
+// the operation it belongs to has finished.
+func (tags *Tags) ClearCheckpoint(ctx context.Context, name string) error {
+	ctx, cancel := tags.context(ctx)
+	defer cancel()
+	_, err := tags.db.ExecContext(ctx, tags.sql(checkpointDelete), name)
+	return err
+}
+
+func (tags *Tags) checkpoint(ctx context.Context, q querier, name string) (string, bool, error) {
+	var cursor string
+	err := q.QueryRowContext(ctx, tags.sql(checkpointQuery), name).Scan(&cursor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	return cursor, err == nil, err
+}
+
+func (tags *Tags) saveCheckpoint(ctx context.Context, q querier, name, cursor string) error {
+	_, err := q.ExecContext(ctx, tags.sql(checkpointUpsert), name, cursor, time.Now().UTC())
+	return err
+}
+
+// resumeFrom returns the number of records already processed according to
+// the checkpoint with the given name, or zero if there is no checkpoint.
+func (tags *Tags) resumeFrom(ctx context.Context, name string) (int, error) {
+	if name == "" {
+		return 0, nil
+	}
+	cursor, ok, err := tags.Checkpoint(ctx, name)
+	if err != nil || !ok {
+		return 0, err
+	}
+	return strconv.Atoi(cursor)
+}
@@ -0,0 +1,76 @@
+package tango
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestCheckpoints(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if _, ok, err := tags.Checkpoint(ctx, "migration"); err != nil || ok {
+		t.Errorf("Expected checkpoint not to exist (%v, %v)", ok, err)
+	}
+	if err := tags.SaveCheckpoint(ctx, "migration", "abc"); err != nil {
+		t.Error(err)
+	}
+	if err := tags.SaveCheckpoint(ctx, "migration", "def"); err != nil {
+		t.Error(err)
+	}
+	if cursor, ok, err := tags.Checkpoint(ctx, "migration"); err != nil || !ok || cursor != "def" {
+		t.Errorf("Expected checkpoint to be def, was %s (%v, %v)", cursor, ok, err)
+	}
+	if err := tags.ClearCheckpoint(ctx, "migration"); err != nil {
+		t.Error(err)
+	}
+	if _, ok, err := tags.Checkpoint(ctx, "migration"); err != nil || ok {
+		t.Errorf("Expected checkpoint not to exist (%v, %v)", ok, err)
+	}
+}
+
+func TestCheckpointsBulkUpsert(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	var records []Record
+	for i := 0; i < 25; i++ {
+		records = append(records, Record{Universe: "1234", Entity: strconv.Itoa(i), Key: "points", Value: json.RawMessage(`1`)})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err = tags.BulkUpsert(ctx, records, BulkOptions{ChunkSize: 10, Checkpoint: "import", Progress: ProgressFunc(func(ProgressInfo) {
+		cancel()
+	})})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the operation to be cancelled, got %v", err)
+	}
+	if cursor, _, err := tags.Checkpoint(context.Background(), "import"); err != nil || cursor != "10" {
+		t.Errorf("Expected checkpoint to be 10, was %s (%v)", cursor, err)
+	}
+
+	// Running it again skips the records already written.
+	var processed []int
+	written, err := tags.BulkUpsert(context.Background(), records, BulkOptions{ChunkSize: 10, Checkpoint: "import", Progress: ProgressFunc(func(info ProgressInfo) {
+		processed = append(processed, info.Processed)
+	})})
+	if err != nil || written != 25 {
+		t.Errorf("Expected the operation to finish, written %d (%v)", written, err)
+	}
+	if len(processed) != 2 || processed[0] != 20 {
+		t.Errorf("Expected the operation to resume after 10 records, got %v", processed)
+	}
+	if _, ok, err := tags.Checkpoint(context.Background(), "import"); err != nil || ok {
+		t.Errorf("Expected checkpoint to be cleared (%v, %v)", ok, err)
+	}
+}
@@ -0,0 +1,42 @@
+package tango
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// A Codec converts values into the representation stored in the database
+// and back. Since the engine relies on the JSON functions of the database
+// for some features, codecs must produce valid JSON. Custom codecs are
+// useful to tune how values are encoded, for instance to decode numbers
+// as json.Number or to use a faster JSON implementation.
+type Codec interface {
+	Marshal(value any) ([]byte, error)
+	Unmarshal(data []byte, out any) error
+}
+
+// JSONCodec is the default codec, which uses the encoding/json package.
+type JSONCodec struct{}
+
+// Marshal returns the JSON encoding of the value.
+func (JSONCodec) Marshal(value any) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// Unmarshal parses the JSON encoded data into the out variable.
+func (JSONCodec) Unmarshal(data []byte, out any) error {
+	return json.Unmarshal(data, out)
+}
+
+// decode parses the given representation of the value of the tag into the
+// out variable using the codec of the engine. If the value does not fit
+// into the out variable, the error also wraps ErrTypeMismatch.
+func (tag *Tag) decode(raw []byte, out any) error {
+	err := tag.tags.codec.Unmarshal(raw, out)
+	var mismatch *json.UnmarshalTypeError
+	if errors.As(err, &mismatch) {
+		return fmt.Errorf("%w: %w", ErrTypeMismatch, err)
+	}
+	return err
+}
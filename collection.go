@@ -0,0 +1,167 @@
+package tango
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+)
+
+// An Eviction is the policy used by a collection to decide which items are
+// dropped when the collection grows beyond its capacity.
+type Eviction int
+
+const (
+	// FIFO drops the items that were added first. Adding an item that is
+	// already part of the collection adds it again.
+	FIFO Eviction = iota
+
+	// LRU drops the items that were used least recently. Adding or
+	// touching an item that is already part of the collection moves it to
+	// the end of the collection instead of adding it again.
+	LRU
+)
+
+// A Collection is a view of a tag that holds a list of items capped to a
+// maximum size. When the collection grows beyond its capacity, items are
+// evicted according to its eviction policy. Every change is done in a
+// transaction, so the eviction is applied atomically.
+type Collection struct {
+	tag    *Tag
+	max    int
+	policy Eviction
+}
+
+// Collection returns a view of this tag as a collection that holds at most
+// max items and evicts items using the given policy. If max is zero or
+// negative, the collection will not be capped.
+func (tag *Tag) Collection(max int, policy Eviction) *Collection {
+	return &Collection{tag: tag, max: max, policy: policy}
+}
+
+// Add inserts an item at the end of the collection, evicting items if the
+// collection grows beyond its capacity.
+func (c *Collection) Add(item any) error {
+	raw, err := marshalCompact(item)
+	if err != nil {
+		return err
+	}
+	return c.modify(func(items []json.RawMessage) []json.RawMessage {
+		if c.policy == LRU {
+			items = removeItem(items, raw)
+		}
+		return append(items, raw)
+	})
+}
+
+// Touch marks an item as used, moving it to the end of the collection if
+// the policy is LRU. It does nothing if the item is not in the collection
+// or if the policy is FIFO.
+func (c *Collection) Touch(item any) error {
+	if c.policy != LRU {
+		return nil
+	}
+	raw, err := marshalCompact(item)
+	if err != nil {
+		return err
+	}
+	return c.modify(func(items []json.RawMessage) []json.RawMessage {
+		count := len(items)
+		items = removeItem(items, raw)
+		if len(items) < count {
+			items = append(items, raw)
+		}
+		return items
+	})
+}
+
+// Remove drops every occurrence of the item from the collection.
+func (c *Collection) Remove(item any) error {
+	raw, err := marshalCompact(item)
+	if err != nil {
+		return err
+	}
+	return c.modify(func(items []json.RawMessage) []json.RawMessage {
+		return removeItem(items, raw)
+	})
+}
+
+// Items returns the JSON representation of the items in the collection,
+// from the first to the last one.
+func (c *Collection) Items() ([]json.RawMessage, error) {
+	c.tag.warnDeprecated("get")
+	if err := c.tag.tags.checkUndecorated(); err != nil {
+		return nil, c.tag.tags.failed("get", c.tag, err)
+	}
+	ctx, cancel := c.tag.tags.context(context.Background())
+	defer cancel()
+	items, err := c.items(ctx, c.tag.tags.db)
+	return items, c.tag.tags.finish("get", c.tag, err)
+}
+
+// Decode puts the items of the collection into the out variable, which
+// should be a pointer to a slice.
+func (c *Collection) Decode(out any) (bool, error) {
+	return c.tag.Get(out)
+}
+
+// modify applies the given change to the items of the collection and then
+// evicts the items beyond the capacity, as part of a transaction.
+func (c *Collection) modify(change func([]json.RawMessage) []json.RawMessage) error {
+	c.tag.warnDeprecated("set")
+	if err := c.tag.tags.checkUndecorated(); err != nil {
+		return c.tag.tags.failed("set", c.tag, err)
+	}
+	ctx, cancel := c.tag.tags.context(context.Background())
+	defer cancel()
+	err := c.tag.tags.transaction(ctx, func(tx *txn) error {
+		items, err := c.items(tx.ctx, tx)
+		if err != nil {
+			return err
+		}
+		items = change(items)
+		if c.max > 0 && len(items) > c.max {
+			items = items[len(items)-c.max:]
+		}
+		return c.tag.setTx(tx, items)
+	})
+	return c.tag.tags.finish("set", c.tag, err)
+}
+
+func (c *Collection) items(ctx context.Context, q querier) ([]json.RawMessage, error) {
+	raw, exists, err := c.tag.fetchKey(ctx, q, c.tag.key)
+	if err != nil || !exists {
+		return nil, err
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// marshalCompact returns the compact JSON representation of the value, so
+// that it can be compared with other representations.
+func marshalCompact(value any) (json.RawMessage, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// removeItem returns the list of items without any occurrence of item.
+func removeItem(items []json.RawMessage, item json.RawMessage) []json.RawMessage {
+	result := make([]json.RawMessage, 0, len(items))
+	for _, other := range items {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, other); err == nil && bytes.Equal(buf.Bytes(), item) {
+			continue
+		}
+		result = append(result, other)
+	}
+	return result
+}
@@ -0,0 +1,92 @@
+package tango
+
+import "testing"
+
+func TestCollectionFIFO(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	c := tags.Tag("1234", "5678", "songs").Collection(3, FIFO)
+	for _, song := range []string{"a", "b", "a", "c", "d"} {
+		if err := c.Add(song); err != nil {
+			t.Error(err)
+		}
+	}
+
+	var result []string
+	if _, err := c.Decode(&result); err != nil {
+		t.Error(err)
+	}
+	expected := []string{"a", "c", "d"}
+	if len(result) != len(expected) {
+		t.Fatalf("Expected collection to have length %d, was %d", len(expected), len(result))
+	}
+	for i, e := range expected {
+		if result[i] != e {
+			t.Errorf("Expected item %d to be %s, was %s", i, e, result[i])
+		}
+	}
+}
+
+func TestCollectionLRU(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	c := tags.Tag("1234", "5678", "songs").Collection(3, LRU)
+	for _, song := range []string{"a", "b", "c", "a"} {
+		if err := c.Add(song); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := c.Touch("b"); err != nil {
+		t.Error(err)
+	}
+	if err := c.Add("d"); err != nil {
+		t.Error(err)
+	}
+
+	var result []string
+	if _, err := c.Decode(&result); err != nil {
+		t.Error(err)
+	}
+	expected := []string{"a", "b", "d"}
+	if len(result) != len(expected) {
+		t.Fatalf("Expected collection to have length %d, was %d", len(expected), len(result))
+	}
+	for i, e := range expected {
+		if result[i] != e {
+			t.Errorf("Expected item %d to be %s, was %s", i, e, result[i])
+		}
+	}
+}
+
+func TestCollectionRemove(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	c := tags.Tag("1234", "5678", "songs").Collection(0, FIFO)
+	for _, song := range []any{map[string]int{"id": 1}, map[string]int{"id": 2}, map[string]int{"id": 1}} {
+		if err := c.Add(song); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := c.Remove(map[string]int{"id": 1}); err != nil {
+		t.Error(err)
+	}
+	items, err := c.Items()
+	if err != nil {
+		t.Error(err)
+	}
+	if len(items) != 1 || string(items[0]) != `{"id":2}` {
+		t.Errorf("Expected collection to be [{\"id\":2}], was %s", items)
+	}
+}
@@ -0,0 +1,194 @@
+package tango
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A ConfigView is a snapshot of the tags of an entity that is kept in
+// memory and refreshed periodically in the background. It is safe to use
+// from multiple goroutines, and it is useful for long running workers that
+// need current settings without reading the tags on every iteration.
+type ConfigView struct {
+	bag   *TagBag
+	every time.Duration
+
+	mu     sync.RWMutex
+	values map[string]json.RawMessage
+	err    error
+
+	changed chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// ConfigView returns a view of the tags of the given entity that reloads
+// every reloadEvery, or only when Reload is called if reloadEvery is zero.
+// Values are read like Tag.Get reads them, so the view also holds the
+// default values and the derived keys, and aliases and references are
+// resolved. The view is loaded before returning, so an error is returned
+// if the first load fails. The view should be closed when it is not needed
+// anymore to stop the background reloads.
+func (tags *Tags) ConfigView(universe, entity string, reloadEvery time.Duration) (*ConfigView, error) {
+	if reloadEvery < 0 {
+		return nil, fmt.Errorf("tango: negative reload interval %s", reloadEvery)
+	}
+	view := &ConfigView{
+		bag:     tags.TagBag(universe, entity),
+		every:   reloadEvery,
+		changed: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	if err := view.Reload(); err != nil {
+		return nil, err
+	}
+	if reloadEvery > 0 {
+		go view.loop()
+	}
+	return view, nil
+}
+
+func (view *ConfigView) loop() {
+	ticker := time.NewTicker(view.every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-view.done:
+			return
+		case <-ticker.C:
+			view.Reload()
+		}
+	}
+}
+
+// Reload reads the tags of the entity again. If the tags changed since the
+// last time they were read, a notification is sent through the channel
+// returned by Changed. Errors are also kept and returned by Err, and the
+// previous snapshot is kept until a reload succeeds.
+func (view *ConfigView) Reload() error {
+	ctx, cancel := view.bag.tags.context(context.Background())
+	defer cancel()
+	values, err := view.load(ctx)
+
+	view.mu.Lock()
+	view.err = err
+	changed := false
+	if err == nil && view.values != nil && !sameValues(view.values, values) {
+		changed = true
+	}
+	if err == nil {
+		view.values = values
+	}
+	view.mu.Unlock()
+
+	if changed {
+		select {
+		case view.changed <- struct{}{}:
+		default:
+		}
+	}
+	return err
+}
+
+// load reads every tag of the entity through the store of the engine,
+// including the keys that have a default value or that are derived.
+func (view *ConfigView) load(ctx context.Context) (map[string]json.RawMessage, error) {
+	tags := view.bag.tags
+	keys, err := tags.store.ListTags(ctx, view.bag.universe, view.bag.entity)
+	if err != nil {
+		return nil, err
+	}
+	var implicit []string
+	for key := range tags.defaults {
+		implicit = append(implicit, key)
+	}
+	for key := range tags.derived {
+		implicit = append(implicit, key)
+	}
+	keys = append(keys, tags.unscope(implicit)...)
+
+	values := make(map[string]json.RawMessage)
+	for _, key := range keys {
+		name := strings.TrimPrefix(view.bag.Tag(key).key, tags.keyPrefix)
+		if _, ok := values[name]; ok {
+			continue
+		}
+		raw, exists, err := tags.store.GetTag(ctx, view.bag.universe, view.bag.entity, name)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			values[name] = raw
+		}
+	}
+	return values, nil
+}
+
+// Changed returns a channel that receives a notification every time a
+// reload finds that the tags changed. Notifications are coalesced, so a
+// single notification may stand for multiple changes.
+func (view *ConfigView) Changed() <-chan struct{} {
+	return view.changed
+}
+
+// Err returns the error of the last reload, or nil if it succeeded.
+func (view *ConfigView) Err() error {
+	view.mu.RLock()
+	defer view.mu.RUnlock()
+	return view.err
+}
+
+// Get puts the value of the given tag from the snapshot into the out
+// variable, returning whether the tag is set.
+func (view *ConfigView) Get(key string, out any) (bool, error) {
+	view.mu.RLock()
+	raw, ok := view.values[key]
+	view.mu.RUnlock()
+	if !ok {
+		return false, nil
+	}
+	tag := view.bag.Tag(key)
+	if err := tag.decode(raw, out); err != nil {
+		return true, view.bag.tags.wrapError("get", tag, err)
+	}
+	return true, nil
+}
+
+// Load puts the snapshot into the out variable, which is usually a pointer
+// to a struct whose fields are bound to the keys of the tags using json
+// struct tags. Tags that are not set leave their fields untouched.
+func (view *ConfigView) Load(out any) error {
+	view.mu.RLock()
+	raw, err := json.Marshal(view.values)
+	view.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return view.bag.tags.codec.Unmarshal(raw, out)
+}
+
+// Close stops the background reloads.
+func (view *ConfigView) Close() {
+	view.once.Do(func() {
+		close(view.done)
+	})
+}
+
+// sameValues returns whether both snapshots hold the same tags.
+func sameValues(a, b map[string]json.RawMessage) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		other, ok := b[key]
+		if !ok || !bytes.Equal(value, other) {
+			return false
+		}
+	}
+	return true
+}
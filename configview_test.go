@@ -0,0 +1,128 @@
+package tango
+
+import (
+	"testing"
+	"time"
+)
+
+type botConfig struct {
+	Prefix  string `json:"prefix"`
+	Enabled bool   `json:"enabled"`
+}
+
+func TestConfigViewLoad(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', 'config', 'prefix', '"!"'),
+		('1234', 'config', 'enabled', 'true')`); err != nil {
+		t.Error(err)
+	}
+
+	view, err := tags.ConfigView("1234", "config", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer view.Close()
+
+	var config botConfig
+	if err := view.Load(&config); err != nil {
+		t.Error(err)
+	}
+	if config.Prefix != "!" || !config.Enabled {
+		t.Errorf("Unexpected config %+v", config)
+	}
+	var prefix string
+	if ok, err := view.Get("prefix", &prefix); err != nil || !ok || prefix != "!" {
+		t.Errorf("Expected prefix to be !, was %s (%v, %v)", prefix, ok, err)
+	}
+}
+
+func TestConfigViewChanged(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if err := tags.Tag("1234", "config", "prefix").Set("!"); err != nil {
+		t.Error(err)
+	}
+	view, err := tags.ConfigView("1234", "config", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer view.Close()
+
+	// Reloading without changes does not notify.
+	if err := view.Reload(); err != nil {
+		t.Error(err)
+	}
+	select {
+	case <-view.Changed():
+		t.Errorf("Unexpected change notification")
+	default:
+	}
+
+	if err := tags.Tag("1234", "config", "prefix").Set("?"); err != nil {
+		t.Error(err)
+	}
+	if err := view.Reload(); err != nil {
+		t.Error(err)
+	}
+	select {
+	case <-view.Changed():
+	default:
+		t.Errorf("Expected change notification")
+	}
+	var config botConfig
+	if err := view.Load(&config); err != nil {
+		t.Error(err)
+	}
+	if config.Prefix != "?" {
+		t.Errorf("Expected prefix to be ?, was %s", config.Prefix)
+	}
+}
+
+func TestConfigViewResolves(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db,
+		WithManifest(NewManifest(Setting{Key: "prefix", Kind: KindString, Default: "!"})),
+		WithAlias("on", "enabled"),
+		WithDerived("greeting", func(e *Entity) (any, bool, error) {
+			return "hello", true, nil
+		}),
+	)
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', 'config', 'on', 'true')`); err != nil {
+		t.Error(err)
+	}
+
+	view, err := tags.ConfigView("1234", "config", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer view.Close()
+	var config botConfig
+	if err := view.Load(&config); err != nil {
+		t.Error(err)
+	}
+	if config.Prefix != "!" || !config.Enabled {
+		t.Errorf("Expected the default and the aliased value, got %+v", config)
+	}
+	var greeting string
+	if ok, err := view.Get("greeting", &greeting); err != nil || !ok || greeting != "hello" {
+		t.Errorf("Expected the derived key, got %s (%v, %v)", greeting, ok, err)
+	}
+
+	if _, err := tags.ConfigView("1234", "config", -time.Second); err == nil {
+		t.Errorf("Expected a negative reload interval to fail")
+	}
+}
@@ -0,0 +1,213 @@
+package tango
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+var (
+	conformUniverse = `SELECT universe, entity, key, value FROM {table} WHERE universe = ? ORDER BY entity, key`
+	conformAll      = `SELECT universe, entity, key, value FROM {table} ORDER BY universe, entity, key`
+	conformSample   = `SELECT universe, entity, key, value FROM {table} WHERE key IN ({keys}) ORDER BY RANDOM() LIMIT ?`
+)
+
+// ConformOptions tunes how Tags.Conform deals with the values that violate
+// the manifest.
+type ConformOptions struct {
+	// Coerce makes Conform rewrite the values that can be converted into
+	// the kind declared for their setting, such as the string "5" for a
+	// number setting, as long as the converted value satisfies the rest of
+	// the constraints of the setting.
+	Coerce bool
+}
+
+// A Violation is a value stored for a setting that does not conform to the
+// manifest of the engine.
+type Violation struct {
+	Universe string
+	Entity   string
+	Key      string
+	Value    json.RawMessage
+	Err      error
+
+	// Coerced is the value the violation was rewritten into, if it was
+	// coerced. It is nil if the violation was only reported.
+	Coerced json.RawMessage
+}
+
+// Conform checks the values stored in the given universe for every setting
+// declared in the manifest of the engine, and returns the values violating
+// their kind or their constraints, which are useful to clean up data that
+// was stored before the manifest existed. Violations are only reported,
+// unless the options ask to coerce them, in which case the values that can
+// be coerced are rewritten as part of a single transaction.
+func (tags *Tags) Conform(ctx context.Context, universe string, opts ConformOptions) ([]Violation, error) {
+	if err := tags.checkUnscoped(); err != nil {
+		return nil, err
+	}
+	if tags.manifest == nil {
+		return nil, nil
+	}
+	violations, err := tags.violations(ctx, conformUniverse, universe)
+	if err != nil || !opts.Coerce {
+		return violations, err
+	}
+
+	err = tags.transaction(ctx, func(tx *txn) error {
+		return tags.coerceTx(tx, universe, violations)
+	})
+	return violations, err
+}
+
+// coerceTx rewrites the given violations of a universe that can be coerced
+// as part of the given transaction. Every tag is read again, and it is only
+// rewritten if it still holds the value of the violation, so that writes
+// made after the violations were found are not overwritten.
+func (tags *Tags) coerceTx(tx *txn, universe string, violations []Violation) error {
+	for i := range violations {
+		v := &violations[i]
+		setting, _ := tags.manifest.Lookup(v.Key)
+		coerced, ok := coerce(setting.Kind, v.Value)
+		if !ok {
+			continue
+		}
+		tag := &Tag{tags: tags, universe: universe, entity: v.Entity, key: v.Key, name: v.Key}
+		if err := tag.constrain(coerced); err != nil {
+			continue
+		}
+		raw, exists, err := tag.fetchKey(tx.ctx, tx, v.Key)
+		if err != nil {
+			return err
+		}
+		if !exists || raw != string(v.Value) {
+			continue
+		}
+		if err := tag.storeTx(tx, string(coerced)); err != nil {
+			return err
+		}
+		v.Coerced = coerced
+	}
+	return nil
+}
+
+// ValidateManifest checks the values stored in every universe for the
+// settings declared in the manifest of the engine, and returns the values
+// violating their kind or their constraints. If sample is positive, only
+// that many tags picked at random are checked, which is cheaper for large
+// tables but may miss some violations. Unlike Conform, it never rewrites
+// values.
+func (tags *Tags) ValidateManifest(ctx context.Context, sample int) ([]Violation, error) {
+	if err := tags.checkUnscoped(); err != nil {
+		return nil, err
+	}
+	if tags.manifest == nil {
+		return nil, nil
+	}
+	if sample > 0 {
+		// Only the keys of the manifest are sampled, since other keys
+		// cannot violate it.
+		args := make([]any, 0, len(tags.manifest.settings)+1)
+		for _, setting := range tags.manifest.settings {
+			args = append(args, setting.Key)
+		}
+		if len(args) == 0 {
+			return nil, nil
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+		query := strings.Replace(conformSample, "{keys}", placeholders, 1)
+		return tags.violations(ctx, query, append(args, sample)...)
+	}
+	return tags.violations(ctx, conformAll)
+}
+
+// validateOnStart runs the validation requested by WithStartupValidation,
+// and reports the violations found, if any. It is run in the background by
+// NewTagsEngine.
+func (tags *Tags) validateOnStart() {
+	violations, err := tags.ValidateManifest(context.Background(), tags.startupSample)
+	if err != nil {
+		if tags.logger != nil {
+			tags.logger.Printf("tango: startup validation failed: %v", err)
+		}
+		return
+	}
+	if len(violations) > 0 {
+		tags.notifyHook("startup validation", func() {
+			tags.startupReport(violations)
+		})
+	}
+}
+
+// violations returns the values returned by the given query that violate
+// the manifest.
+func (tags *Tags) violations(ctx context.Context, query string, args ...any) ([]Violation, error) {
+	rs, err := tags.db.QueryContext(ctx, tags.sql(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	var violations []Violation
+	for rs.Next() {
+		var universe, entity, key, value string
+		if err := rs.Scan(&universe, &entity, &key, &value); err != nil {
+			return nil, err
+		}
+		if _, ok := tags.manifest.Lookup(key); !ok {
+			continue
+		}
+		tag := &Tag{tags: tags, universe: universe, entity: entity, key: key, name: key}
+		if err := tag.constrain([]byte(value)); err != nil {
+			violations = append(violations, Violation{Universe: universe, Entity: entity, Key: key, Value: json.RawMessage(value), Err: err})
+		}
+	}
+	return violations, rs.Err()
+}
+
+// coerce converts the given JSON representation into the given kind, if it
+// is possible to do so without losing information.
+func coerce(kind Kind, raw json.RawMessage) (json.RawMessage, bool) {
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, false
+	}
+	var coerced any
+	switch kind {
+	case KindString:
+		switch value.(type) {
+		case float64, bool:
+			coerced = strings.TrimSpace(string(raw))
+		}
+	case KindNumber:
+		switch v := value.(type) {
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				coerced = f
+			}
+		case bool:
+			if v {
+				coerced = 1
+			} else {
+				coerced = 0
+			}
+		}
+	case KindBool:
+		switch v := value.(type) {
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				coerced = b
+			}
+		case float64:
+			if v == 0 || v == 1 {
+				coerced = v == 1
+			}
+		}
+	}
+	if coerced == nil {
+		return nil, false
+	}
+	result, err := json.Marshal(coerced)
+	return result, err == nil
+}
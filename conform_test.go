@@ -0,0 +1,165 @@
+package tango
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTagsConform(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithManifest(NewManifest(
+		Setting{Key: "points", Kind: KindNumber},
+		Setting{Key: "enabled", Kind: KindBool},
+		Setting{Key: "log_level", Kind: KindString, Enum: []any{"off", "all"}},
+	)))
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', 'a', 'points', '"33"'),
+		('1234', 'a', 'enabled', 'true'),
+		('1234', 'b', 'enabled', '"yes"'),
+		('1234', 'b', 'log_level', '"verbose"'),
+		('1234', 'b', 'undeclared', '"33"'),
+		('4321', 'c', 'points', '"33"')`); err != nil {
+		t.Error(err)
+	}
+
+	violations, err := tags.Conform(context.Background(), "1234", ConformOptions{})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(violations) != 3 {
+		t.Fatalf("Expected 3 violations, got %+v", violations)
+	}
+	if v := violations[0]; v.Entity != "a" || v.Key != "points" || !errors.Is(v.Err, ErrTypeMismatch) || v.Coerced != nil {
+		t.Errorf("Unexpected violation %+v", v)
+	}
+	if v := violations[2]; v.Entity != "b" || v.Key != "log_level" || !errors.Is(v.Err, ErrInvalidEnum) {
+		t.Errorf("Unexpected violation %+v", v)
+	}
+
+	violations, err = tags.Conform(context.Background(), "1234", ConformOptions{Coerce: true})
+	if err != nil {
+		t.Error(err)
+	}
+	if string(violations[0].Coerced) != "33" {
+		t.Errorf("Expected points to be coerced, got %+v", violations[0])
+	}
+	if violations[1].Coerced != nil || violations[2].Coerced != nil {
+		t.Errorf("Expected other violations not to be coerced, got %+v", violations)
+	}
+	var points int
+	if _, err := tags.Tag("1234", "a", "points").Get(&points); err != nil || points != 33 {
+		t.Errorf("Expected points to be 33, was %d (%v)", points, err)
+	}
+	violations, err = tags.Conform(context.Background(), "1234", ConformOptions{})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(violations) != 2 {
+		t.Errorf("Expected 2 violations left, got %+v", violations)
+	}
+}
+
+func TestTagsConformConcurrentWrite(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithManifest(NewManifest(Setting{Key: "points", Kind: KindNumber})))
+	ctx := context.Background()
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', 'a', 'points', '"33"')`); err != nil {
+		t.Error(err)
+	}
+	violations, err := tags.Conform(ctx, "1234", ConformOptions{})
+	if err != nil || len(violations) != 1 {
+		t.Fatalf("Expected 1 violation, got %+v (%v)", violations, err)
+	}
+
+	// A write made after the violations were found is not overwritten.
+	if err := tags.Tag("1234", "a", "points").Set(40); err != nil {
+		t.Error(err)
+	}
+	err = tags.transaction(ctx, func(tx *txn) error {
+		return tags.coerceTx(tx, "1234", violations)
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if violations[0].Coerced != nil {
+		t.Errorf("Expected the stale violation not to be coerced, got %+v", violations[0])
+	}
+	var points int
+	if _, err := tags.Tag("1234", "a", "points").Get(&points); err != nil || points != 40 {
+		t.Errorf("Expected points to be 40, was %d (%v)", points, err)
+	}
+}
+
+func TestStartupValidation(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', 'a', 'points', '"33"'),
+		('1234', 'b', 'points', '33'),
+		('4321', 'c', 'points', 'true')`); err != nil {
+		t.Error(err)
+	}
+	manifest := NewManifest(Setting{Key: "points", Kind: KindNumber})
+
+	reports := make(chan []Violation, 1)
+	NewTagsEngine(db, WithManifest(manifest), WithStartupValidation(0, func(violations []Violation) {
+		reports <- violations
+	}))
+	select {
+	case reported := <-reports:
+		if len(reported) != 2 {
+			t.Fatalf("Expected 2 violations to be reported, got %+v", reported)
+		}
+		if v := reported[1]; v.Universe != "4321" || v.Entity != "c" || !errors.Is(v.Err, ErrTypeMismatch) {
+			t.Errorf("Unexpected violation %+v", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the violations to be reported")
+	}
+
+	// Only the keys of the manifest are sampled.
+	if _, err := db.Exec(`DELETE FROM tags WHERE entity = 'b'`); err != nil {
+		t.Error(err)
+	}
+	for i := 0; i < 20; i++ {
+		if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', ?, 'other', 'true')`, i); err != nil {
+			t.Error(err)
+		}
+	}
+	NewTagsEngine(db, WithManifest(manifest), WithStartupValidation(1, func(violations []Violation) {
+		reports <- violations
+	}))
+	select {
+	case reported := <-reports:
+		if len(reported) != 1 {
+			t.Errorf("Expected 1 violation to be sampled, got %+v", reported)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the sampled violation to be reported")
+	}
+
+	NewTagsEngine(db, WithStartupValidation(0, func(violations []Violation) {
+		reports <- violations
+	}))
+	select {
+	case <-reports:
+		t.Errorf("Expected no validation without a manifest")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,84 @@
+package tango
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// A constraint checks the JSON representation of a value that is about to
+// be stored for a key, returning an error if it is not acceptable.
+type constraint func(raw []byte) error
+
+// constrain checks the constraints registered for the key of this tag.
+func (tag *Tag) constrain(raw []byte) error {
+	for _, check := range tag.tags.constraints[tag.key] {
+		if err := check(raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kindConstraint returns a constraint that only accepts values of the given
+// kind.
+func kindConstraint(kind Kind) constraint {
+	return func(raw []byte) error {
+		if actual := kindOf(string(raw)); actual != kind {
+			return fmt.Errorf("%w: expected %s, got %s", ErrTypeMismatch, kind, actual)
+		}
+		return nil
+	}
+}
+
+// enumConstraint returns a constraint that only accepts values equal to
+// one of the allowed values. Values are compared after decoding them, so
+// that equal numbers with different representations are equal.
+func enumConstraint(allowed []any) constraint {
+	normalized := make([]any, len(allowed))
+	for i, value := range allowed {
+		normalized[i] = normalize(value)
+	}
+	return func(raw []byte) error {
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+		for _, candidate := range normalized {
+			if reflect.DeepEqual(value, candidate) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrInvalidEnum, raw)
+	}
+}
+
+// rangeConstraint returns a constraint that only accepts numbers between
+// min and max, both included.
+func rangeConstraint(min, max float64) constraint {
+	return func(raw []byte) error {
+		// Decoding null into a number leaves it untouched, so it is decoded
+		// into a pointer that remains nil.
+		var value *float64
+		if err := json.Unmarshal(raw, &value); err != nil || value == nil {
+			return fmt.Errorf("%w: %s is not a number", ErrOutOfRange, raw)
+		}
+		if *value < min || *value > max {
+			return fmt.Errorf("%w: %s is not between %g and %g", ErrOutOfRange, raw, min, max)
+		}
+		return nil
+	}
+}
+
+// normalize returns the value as it would be decoded from JSON.
+func normalize(value any) any {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized any
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
@@ -0,0 +1,61 @@
+package tango
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConstraintEnum(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithEnum("log_level", "off", "errors", "all"), WithEnum("slowmode", 0, 5, 10))
+
+	tag := tags.Tag("1234", "5678", "log_level")
+	if err := tag.Set("errors"); err != nil {
+		t.Error(err)
+	}
+	if err := tag.Set("eror"); !errors.Is(err, ErrInvalidEnum) {
+		t.Errorf("Expected ErrInvalidEnum, got %v", err)
+	}
+	var result string
+	if _, err := tag.Get(&result); err != nil || result != "errors" {
+		t.Errorf("Expected key to resolve to 'errors', was `%s` (%v)", result, err)
+	}
+
+	// Numbers are compared by value.
+	if err := tags.Tag("1234", "5678", "slowmode").Set(5.0); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "5678", "slowmode").Set(7); !errors.Is(err, ErrInvalidEnum) {
+		t.Errorf("Expected ErrInvalidEnum, got %v", err)
+	}
+}
+
+func TestConstraintRange(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithRange("xp_multiplier", 0, 10))
+
+	tag := tags.Tag("1234", "5678", "xp_multiplier")
+	if err := tag.Set(2.5); err != nil {
+		t.Error(err)
+	}
+	if err := tag.Set(10); err != nil {
+		t.Error(err)
+	}
+	for _, invalid := range []any{1e9, -1, "5", nil} {
+		if err := tag.Set(invalid); !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("Expected ErrOutOfRange for %v, got %v", invalid, err)
+		}
+	}
+	var result float64
+	if _, err := tag.Get(&result); err != nil || result != 10 {
+		t.Errorf("Expected key to resolve to 10, was %f (%v)", result, err)
+	}
+}
@@ -0,0 +1,67 @@
+package tango
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// A Deprecation describes an usage of a deprecated key.
+type Deprecation struct {
+	Universe string
+	Entity   string
+
+	// Key is the name used to request the tag, and Target is the name of
+	// the key actually used, which is different when Key is an alias.
+	Key    string
+	Target string
+
+	// Op is the operation that was attempted: get, set or delete.
+	Op string
+
+	// Caller is the file and line of the code that used the deprecated
+	// key, outside of this package, if it could be found.
+	Caller string
+}
+
+// warnDeprecated calls the deprecation hook if this tag was requested
+// using a name that has been marked as deprecated.
+func (tag *Tag) warnDeprecated(op string) {
+	hook := tag.tags.deprecationHook
+	if hook == nil {
+		return
+	}
+	if !tag.tags.deprecated[tag.name] && !tag.tags.deprecated[tag.key] {
+		return
+	}
+	deprecation := Deprecation{
+		Universe: tag.universe,
+		Entity:   tag.entity,
+		Key:      tag.name,
+		Target:   tag.key,
+		Op:       op,
+		Caller:   externalCaller(),
+	}
+	tag.tags.notifyHook("deprecation hook", func() {
+		hook(deprecation)
+	})
+}
+
+// externalCaller returns the location of the first frame in the stack that
+// does not belong to this package, formatted as file:line. Tests of this
+// package are considered external code.
+func externalCaller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		internal := strings.HasPrefix(frame.Function, "gopkg.makigas.es/tango.")
+		if !internal || strings.HasSuffix(frame.File, "_test.go") {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package tango
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeprecationHook(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	var events []Deprecation
+	tags := NewTagsEngine(db,
+		WithAlias("lang", "locale"),
+		WithDeprecatedKeys("lang", "legacy"),
+		WithDeprecationHook(func(d Deprecation) {
+			events = append(events, d)
+		}))
+
+	var result string
+	if err := tags.Tag("1234", "5678", "lang").Set("es"); err != nil {
+		t.Error(err)
+	}
+	if _, err := tags.Tag("1234", "5678", "locale").Get(&result); err != nil {
+		t.Error(err)
+	}
+	if _, err := tags.Tag("1234", "5678", "legacy").Get(&result); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "5678", "legacy").Delete(); err != nil {
+		t.Error(err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 deprecation events, got %d", len(events))
+	}
+	expected := []Deprecation{
+		{Key: "lang", Target: "locale", Op: "set"},
+		{Key: "legacy", Target: "legacy", Op: "get"},
+		{Key: "legacy", Target: "legacy", Op: "delete"},
+	}
+	for i, e := range expected {
+		if events[i].Key != e.Key || events[i].Target != e.Target || events[i].Op != e.Op {
+			t.Errorf("Expected event %d to be %v, was %v", i, e, events[i])
+		}
+		if !strings.Contains(events[i].Caller, "deprecation_test.go") {
+			t.Errorf("Expected caller to be in the test file, was %s", events[i].Caller)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package tango
+
+import "context"
+
+// A Derivation computes the value of a derived key for an entity, usually
+// from the values of other keys of the entity. It returns the value and
+// true, or false if the derived key should be reported as not set.
+type Derivation func(entity *Entity) (any, bool, error)
+
+// fetchDerived returns the value of this tag computed using the given
+// derivation. If the dependencies of the key were declared using
+// WithDependencies, the computed value is cached, since writes to the
+// dependencies will evict it.
+func (tag *Tag) fetchDerived(ctx context.Context, derivation Derivation) (string, bool, error) {
+	if _, ok := tag.tags.dependencies[tag.key]; !ok {
+		return tag.derive(derivation)
+	}
+	cfg := opConfigFrom(ctx)
+	if raw, exists, ok := tag.tags.cache.get(tag.universe, tag.entity, tag.key); ok && !cfg.skipCache {
+		tag.tags.stats.cacheHit(tag.universe)
+		return raw, exists, nil
+	} else if tag.tags.cache != nil {
+		tag.tags.stats.cacheMiss(tag.universe)
+	}
+	raw, exists, err := tag.derive(derivation)
+	if err != nil {
+		return "", false, err
+	}
+	tag.tags.cache.put(tag.universe, tag.entity, tag.key, raw, exists, cfg.ttl)
+	return raw, exists, nil
+}
+
+// dependents returns every key that depends on the given key, directly or
+// through other keys.
+func (tags *Tags) dependents(key string) []string {
+	var result []string
+	visited := map[string]bool{key: true}
+	pending := []string{key}
+	for len(pending) > 0 {
+		key, pending = pending[0], pending[1:]
+		for _, dependent := range tags.dependentKeys[key] {
+			if !visited[dependent] {
+				visited[dependent] = true
+				result = append(result, dependent)
+				pending = append(pending, dependent)
+			}
+		}
+	}
+	return result
+}
+
+// derive computes the value of this tag using the given derivation,
+// returning its JSON representation.
+func (tag *Tag) derive(derivation Derivation) (string, bool, error) {
+	var value any
+	var ok bool
+	err := tag.tags.callHook("derivation", func() (err error) {
+		value, ok, err = derivation(tag.tags.Entity(tag.universe, tag.entity))
+		return err
+	})
+	if err != nil || !ok {
+		return "", false, err
+	}
+	raw, err := tag.tags.codec.Marshal(value)
+	if err != nil {
+		return "", false, err
+	}
+	return string(raw), true, nil
+}
+
+// checkWritable returns ErrDerivedKey if the key of this tag is derived,
+// and ErrReadOnly if the engine is a read only scope.
+func (tag *Tag) checkWritable() error {
+	if tag.tags.readOnly {
+		return ErrReadOnly
+	}
+	if _, ok := tag.tags.derived[tag.key]; ok {
+		return ErrDerivedKey
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package tango
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDerivedKeys(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithDerived("display_rank", func(e *Entity) (any, bool, error) {
+		points, ok, err := e.Int("points")
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		if points >= 100 {
+			return "gold", true, nil
+		}
+		return "bronze", true, nil
+	}))
+
+	e := tags.Entity("1234", "5678")
+	if _, ok, err := e.String("display_rank"); err != nil || ok {
+		t.Errorf("Expected display_rank not to be set (%v, %v)", ok, err)
+	}
+	if err := e.Set("points", 10); err != nil {
+		t.Error(err)
+	}
+	if rank, ok, err := e.String("display_rank"); err != nil || !ok || rank != "bronze" {
+		t.Errorf("Expected display_rank to be bronze, was `%s` (%v, %v)", rank, ok, err)
+	}
+	if err := e.Set("points", 150); err != nil {
+		t.Error(err)
+	}
+	if rank, ok, err := e.String("display_rank"); err != nil || !ok || rank != "gold" {
+		t.Errorf("Expected display_rank to be gold, was `%s` (%v, %v)", rank, ok, err)
+	}
+
+	if err := e.Set("display_rank", "platinum"); !errors.Is(err, ErrDerivedKey) {
+		t.Errorf("Expected ErrDerivedKey, got %v", err)
+	}
+	if err := e.Delete("display_rank"); !errors.Is(err, ErrDerivedKey) {
+		t.Errorf("Expected ErrDerivedKey, got %v", err)
+	}
+	list, err := e.Bag().Tags()
+	if err != nil {
+		t.Error(err)
+	}
+	if len(list) != 1 || list[0] != "points" {
+		t.Errorf("Expected derived keys not to be stored, was %v", list)
+	}
+}
+
+func TestDerivedDependencies(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	calls := 0
+	tags := NewTagsEngine(db,
+		WithCache(time.Minute, 0),
+		WithDerived("level", func(e *Entity) (any, bool, error) {
+			calls++
+			points, _, err := e.Int("points")
+			return points / 10, true, err
+		}),
+		WithDerived("title", func(e *Entity) (any, bool, error) {
+			level, _, err := e.Int("level")
+			return fmt.Sprintf("Level %d", level), true, err
+		}),
+		WithDependencies("level", "points"),
+		WithDependencies("title", "level"),
+	)
+
+	e := tags.Entity("1234", "5678")
+	if err := e.Set("points", 10); err != nil {
+		t.Error(err)
+	}
+	for i := 0; i < 3; i++ {
+		if title, _, err := e.String("title"); err != nil || title != "Level 1" {
+			t.Errorf("Expected title to be 'Level 1', was `%s` (%v)", title, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected level to be computed once, was computed %d times", calls)
+	}
+
+	// Writing points invalidates level, and title through level.
+	if err := e.Set("points", 25); err != nil {
+		t.Error(err)
+	}
+	if title, _, err := e.String("title"); err != nil || title != "Level 2" {
+		t.Errorf("Expected title to be 'Level 2', was `%s` (%v)", title, err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected level to be computed again, was computed %d times", calls)
+	}
+}
@@ -0,0 +1,141 @@
+package tango
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ElectionOptions tune a leader election.
+type ElectionOptions struct {
+	// TTL is how long the leadership lasts if the leader stops renewing
+	// it, for example because its process died. It defaults to a minute.
+	TTL time.Duration
+
+	// Interval is how often candidates try to become the leader, and how
+	// often the leader renews its leadership. It defaults to a third of
+	// the TTL, so that the leader has two more attempts to renew the
+	// leadership before it expires.
+	Interval time.Duration
+
+	// OnElected, if set, is called when the candidate becomes the leader.
+	OnElected func()
+
+	// OnDefeated, if set, is called when the candidate stops being the
+	// leader, either because it could not renew the leadership or because
+	// the election was stopped.
+	OnDefeated func()
+}
+
+// An Election elects a leader among several candidates sharing the same
+// store, such as the shards of a bot, so that a single one of them runs
+// the scheduled maintenance. It is built on top of the locks of the store:
+// the leader is the owner of the lock named after the election.
+type Election struct {
+	tags      *Tags
+	name      string
+	candidate string
+	opts      ElectionOptions
+
+	mu     sync.Mutex
+	leader bool
+
+	// renewed is when the leadership was last taken or renewed, so that
+	// it is kept until it expires even if some renewals fail.
+	renewed time.Time
+}
+
+// NewElection returns an election with the given name in which this
+// process takes part as the given candidate, which must be unique among
+// the candidates. The election starts when Run is called.
+func (tags *Tags) NewElection(name, candidate string, opts ElectionOptions) *Election {
+	if opts.TTL <= 0 {
+		opts.TTL = time.Minute
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = opts.TTL / 3
+	}
+	return &Election{tags: tags, name: name, candidate: candidate, opts: opts}
+}
+
+// Run takes part in the election until the context is cancelled, trying to
+// become the leader or renewing the leadership once every interval. When
+// the context is cancelled, the lock is released so that another candidate
+// can take the leadership without waiting for it to expire. It returns the
+// error of the context.
+func (e *Election) Run(ctx context.Context) error {
+	ticker := time.NewTicker(e.opts.Interval)
+	defer ticker.Stop()
+	for {
+		e.campaign(ctx)
+		select {
+		case <-ctx.Done():
+			// The last attempt may have taken the lock even if it failed,
+			// so it is released whether the candidate is the leader or
+			// not. Only the lock of this candidate is released.
+			if err := e.tags.Unlock(context.Background(), e.name, e.candidate); err != nil {
+				e.log(err)
+			}
+			e.setLeader(false)
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// IsLeader returns whether the candidate is currently the leader.
+func (e *Election) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// campaign tries to take or renew the leadership. If the store cannot be
+// reached, the leader keeps the leadership until the TTL since the last
+// renewal has elapsed, since no other candidate can take it before.
+func (e *Election) campaign(ctx context.Context) {
+	attempted := time.Now()
+	locked, err := e.tags.TryLock(ctx, e.name, e.candidate, e.opts.TTL)
+	if err != nil {
+		if ctx.Err() == nil {
+			e.log(err)
+		}
+		e.mu.Lock()
+		expired := time.Since(e.renewed) >= e.opts.TTL
+		e.mu.Unlock()
+		if expired {
+			e.setLeader(false)
+		}
+		return
+	}
+	if locked {
+		e.mu.Lock()
+		e.renewed = attempted
+		e.mu.Unlock()
+	}
+	e.setLeader(locked)
+}
+
+// log reports an error of the election, if the engine has a logger.
+func (e *Election) log(err error) {
+	if e.tags.logger != nil {
+		e.tags.logger.Printf("tango: election %s: %v", e.name, err)
+	}
+}
+
+// setLeader changes whether the candidate is the leader, calling the
+// callbacks if it changes.
+func (e *Election) setLeader(leader bool) {
+	e.mu.Lock()
+	changed := e.leader != leader
+	e.leader = leader
+	e.mu.Unlock()
+	if !changed {
+		return
+	}
+	if leader && e.opts.OnElected != nil {
+		e.tags.notifyHook("elected", e.opts.OnElected)
+	} else if !leader && e.opts.OnDefeated != nil {
+		e.tags.notifyHook("defeated", e.opts.OnDefeated)
+	}
+}
@@ -0,0 +1,114 @@
+package tango
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestElectionCampaign(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	var elected, defeated int
+	opts := ElectionOptions{
+		TTL:        time.Minute,
+		OnElected:  func() { elected++ },
+		OnDefeated: func() { defeated++ },
+	}
+	first := tags.NewElection("janitor", "shard-1", opts)
+	second := tags.NewElection("janitor", "shard-2", ElectionOptions{})
+
+	first.campaign(ctx)
+	second.campaign(ctx)
+	if !first.IsLeader() || second.IsLeader() {
+		t.Errorf("Expected shard-1 to be the only leader")
+	}
+	first.campaign(ctx)
+	if !first.IsLeader() || elected != 1 {
+		t.Errorf("Expected shard-1 to renew the leadership, elected %d times", elected)
+	}
+
+	// Let the leadership of shard-1 expire.
+	if _, err := db.Exec("UPDATE tags_locks SET expires_at = ?", time.Now().UTC().Add(-time.Second)); err != nil {
+		t.Error(err)
+	}
+	second.campaign(ctx)
+	first.campaign(ctx)
+	if first.IsLeader() || !second.IsLeader() {
+		t.Errorf("Expected shard-2 to take the expired leadership")
+	}
+	if defeated != 1 {
+		t.Errorf("Expected shard-1 to be defeated once, was %d", defeated)
+	}
+}
+
+func TestElectionRun(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	elected := make(chan struct{})
+	defeated := make(chan struct{})
+	election := tags.NewElection("janitor", "shard-1", ElectionOptions{
+		TTL:        time.Minute,
+		OnElected:  func() { close(elected) },
+		OnDefeated: func() { close(defeated) },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- election.Run(ctx) }()
+
+	<-elected
+	if !election.IsLeader() {
+		t.Errorf("Expected shard-1 to be the leader")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected Run to return the context error, got %v", err)
+	}
+	<-defeated
+	if election.IsLeader() {
+		t.Errorf("Expected shard-1 to give up the leadership")
+	}
+	locked, err := tags.TryLock(context.Background(), "janitor", "shard-2", time.Minute)
+	if err != nil || !locked {
+		t.Errorf("Expected shard-2 to take the released leadership (%v, %v)", locked, err)
+	}
+}
+
+func TestElectionRenewalFailure(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	election := tags.NewElection("janitor", "shard-1", ElectionOptions{TTL: time.Minute})
+	election.campaign(context.Background())
+	if !election.IsLeader() {
+		t.Fatalf("Expected shard-1 to be the leader")
+	}
+
+	// A failed renewal keeps the leadership until it expires.
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	election.campaign(cancelled)
+	if !election.IsLeader() {
+		t.Errorf("Expected shard-1 to keep the leadership after a failed renewal")
+	}
+	election.mu.Lock()
+	election.renewed = time.Now().Add(-time.Minute)
+	election.mu.Unlock()
+	election.campaign(cancelled)
+	if election.IsLeader() {
+		t.Errorf("Expected shard-1 to lose the leadership once it expired")
+	}
+}
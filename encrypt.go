@@ -0,0 +1,201 @@
+package tango
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrDecryption is returned when an encrypted stream cannot be decrypted,
+// either because the key is wrong or because the stream has been tampered
+// with or truncated.
+var ErrDecryption = errors.New("tango: cannot decrypt stream")
+
+const (
+	// encryptChunkSize is the size of the chunks of plaintext sealed by
+	// an encrypting writer.
+	encryptChunkSize = 64 * 1024
+
+	// encryptLastChunk is set in the length of the last chunk of the
+	// stream, so that truncated streams can be detected.
+	encryptLastChunk = 1 << 31
+)
+
+// An encryptWriter seals the data written to it in chunks using AES-GCM.
+// The stream starts with a random salt, followed by every chunk prefixed
+// by its length. The nonce of every chunk is the salt followed by the
+// number of the chunk.
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	data    []byte
+	salt    [8]byte
+	counter uint32
+	buf     []byte
+}
+
+// NewEncryptWriter returns a writer that encrypts everything written to it
+// into w, using AES-GCM with the given key, which must be 16, 24 or 32
+// bytes long. The writer must be closed to write the last chunk. Streams
+// written by the writer can be decrypted using NewDecryptReader, so it can
+// be used to encrypt any export, such as the one made by WriteUniverseJSON.
+func NewEncryptWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	return newEncryptWriter(w, key, nil)
+}
+
+// newEncryptWriter works like NewEncryptWriter, but also authenticates the
+// given data with every chunk, so that the stream can only be decrypted
+// along with the same data.
+func newEncryptWriter(w io.Writer, key, data []byte) (io.WriteCloser, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	ew := &encryptWriter{w: w, aead: aead, data: data}
+	if _, err := rand.Read(ew.salt[:]); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(ew.salt[:]); err != nil {
+		return nil, err
+	}
+	return ew, nil
+}
+
+func (ew *encryptWriter) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(ew.buf)+len(p) > encryptChunkSize {
+		n := encryptChunkSize - len(ew.buf)
+		ew.buf = append(ew.buf, p[:n]...)
+		p = p[n:]
+		if err := ew.seal(false); err != nil {
+			return 0, err
+		}
+	}
+	ew.buf = append(ew.buf, p...)
+	return written, nil
+}
+
+// Close writes the last chunk of the stream. It does not close the
+// underlying writer.
+func (ew *encryptWriter) Close() error {
+	return ew.seal(true)
+}
+
+// seal encrypts and writes the buffered chunk.
+func (ew *encryptWriter) seal(last bool) error {
+	sealed := ew.aead.Seal(nil, chunkNonce(ew.salt, ew.counter), ew.buf, chunkData(ew.data, last))
+	length := uint32(len(sealed))
+	if last {
+		length |= encryptLastChunk
+	}
+	if err := binary.Write(ew.w, binary.BigEndian, length); err != nil {
+		return err
+	}
+	if _, err := ew.w.Write(sealed); err != nil {
+		return err
+	}
+	ew.counter++
+	ew.buf = ew.buf[:0]
+	return nil
+}
+
+// A decryptReader opens the chunks written by an encryptWriter.
+type decryptReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	data    []byte
+	salt    [8]byte
+	counter uint32
+	buf     []byte
+	done    bool
+}
+
+// NewDecryptReader returns a reader that decrypts a stream written using
+// NewEncryptWriter with the same key. Reads fail with ErrDecryption if the
+// key is wrong or if the stream has been modified or truncated.
+func NewDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	return newDecryptReader(r, key, nil)
+}
+
+// newDecryptReader works like NewDecryptReader for streams written using
+// newEncryptWriter with the given data.
+func newDecryptReader(r io.Reader, key, data []byte) (io.Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	dr := &decryptReader{r: bufio.NewReader(r), aead: aead, data: data}
+	if _, err := io.ReadFull(dr.r, dr.salt[:]); err != nil {
+		return nil, ErrDecryption
+	}
+	return dr, nil
+}
+
+func (dr *decryptReader) Read(p []byte) (int, error) {
+	for len(dr.buf) == 0 {
+		if dr.done {
+			return 0, io.EOF
+		}
+		if err := dr.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, dr.buf)
+	dr.buf = dr.buf[n:]
+	return n, nil
+}
+
+// open reads and decrypts the next chunk.
+func (dr *decryptReader) open() error {
+	var length uint32
+	if err := binary.Read(dr.r, binary.BigEndian, &length); err != nil {
+		return ErrDecryption
+	}
+	dr.done = length&encryptLastChunk != 0
+	length &^= encryptLastChunk
+	if length > encryptChunkSize+uint32(dr.aead.Overhead()) {
+		return ErrDecryption
+	}
+	sealed := make([]byte, length)
+	if _, err := io.ReadFull(dr.r, sealed); err != nil {
+		return ErrDecryption
+	}
+	plain, err := dr.aead.Open(nil, chunkNonce(dr.salt, dr.counter), sealed, chunkData(dr.data, dr.done))
+	if err != nil {
+		return ErrDecryption
+	}
+	dr.counter++
+	dr.buf = plain
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce returns the nonce used to seal the given chunk of a stream.
+func chunkNonce(salt [8]byte, counter uint32) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, salt[:])
+	binary.BigEndian.PutUint32(nonce[8:], counter)
+	return nonce
+}
+
+// chunkData returns the additional data authenticated with every chunk,
+// which is the data given to the stream followed by whether it is the last
+// chunk, so that a truncated stream cannot be passed off as complete.
+func chunkData(data []byte, last bool) []byte {
+	flag := byte(0)
+	if last {
+		flag = 1
+	}
+	return append(append([]byte(nil), data...), flag)
+}
@@ -0,0 +1,47 @@
+package tango
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestEncryptRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	plain := bytes.Repeat([]byte("tango"), encryptChunkSize/2)
+
+	var buf bytes.Buffer
+	w, err := NewEncryptWriter(&buf, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(plain); err != nil {
+		t.Error(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Error(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("tango")) {
+		t.Errorf("Expected the stream to be encrypted")
+	}
+
+	r, err := NewDecryptReader(bytes.NewReader(buf.Bytes()), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(decrypted, plain) {
+		t.Errorf("Expected the stream to be decrypted (%v)", err)
+	}
+
+	// Wrong keys and truncated streams are detected.
+	r, _ = NewDecryptReader(bytes.NewReader(buf.Bytes()), bytes.Repeat([]byte{8}, 32))
+	if _, err := io.ReadAll(r); !errors.Is(err, ErrDecryption) {
+		t.Errorf("Expected ErrDecryption, got %v", err)
+	}
+	r, _ = NewDecryptReader(bytes.NewReader(buf.Bytes()[:encryptChunkSize+100]), key)
+	if _, err := io.ReadAll(r); !errors.Is(err, ErrDecryption) {
+		t.Errorf("Expected ErrDecryption, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package tango
+
+import (
+	"context"
+	"encoding/base64"
+)
+
+var (
+	entitiesRandom       = `SELECT entity FROM (SELECT DISTINCT entity FROM {table} WHERE universe = ?) ORDER BY RANDOM() LIMIT ?`
+	entitiesRandomHaving = `SELECT entity FROM {table} WHERE universe = ? AND key = ? ORDER BY RANDOM() LIMIT ?`
+
+	entitiesPage = `SELECT DISTINCT entity FROM {table} WHERE universe = ? AND entity > ? ORDER BY entity LIMIT ?`
+	keysPage     = `SELECT key FROM {table} WHERE universe = ? AND entity = ? AND substr(key, 1, length(?)) = ? AND key > ? ORDER BY key LIMIT ?`
+)
+
+// RandomEntities returns up to n entities of the given universe picked at
+// random, which is useful for features such as raffles. If havingKey is not
+// empty, only entities that have a tag with that key will be picked. The
+// sampling is done by the database, so entity IDs are never loaded into
+// memory unless picked.
+func (tags *Tags) RandomEntities(universe string, n int, havingKey string) ([]string, error) {
+	if err := tags.checkAddress(universe); err != nil {
+		return nil, err
+	}
+	query, args := entitiesRandom, []any{universe, n}
+	if havingKey != "" {
+		query, args = entitiesRandomHaving, []any{universe, tags.keyPrefix + havingKey, n}
+	}
+	ctx, cancel := tags.context(context.Background())
+	defer cancel()
+	rs, err := tags.db.QueryContext(ctx, tags.sql(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	result := []string{}
+	for rs.Next() {
+		var entity string
+		if err := rs.Scan(&entity); err != nil {
+			return nil, err
+		}
+		result = append(result, entity)
+	}
+	return result, rs.Err()
+}
+
+// Entities returns a page of the entities of the given universe. Entities
+// are always listed in ascending order of their ID, which makes iteration
+// stable even if entities are added or removed while paging. The first page
+// is requested using an empty cursor, and the returned cursor is used to
+// request the next page. When there are no more pages, the returned cursor
+// is empty.
+func (tags *Tags) Entities(universe, cursor string, limit int) ([]string, string, error) {
+	if err := tags.checkAddress(universe); err != nil {
+		return nil, "", err
+	}
+	return tags.page(entitiesPage, cursor, limit, universe)
+}
+
+// TagsPage returns a page of the tags in the current tagbag. Tags are listed
+// in ascending order of their key, and pages are requested using cursors in
+// the same way as Tags.Entities.
+func (bag *TagBag) TagsPage(cursor string, limit int) ([]string, string, error) {
+	if err := bag.tags.checkUndecorated(); err != nil {
+		return nil, "", err
+	}
+	if err := bag.tags.checkAddress(bag.universe, bag.entity); err != nil {
+		return nil, "", err
+	}
+	prefix := bag.tags.keyPrefix
+	keys, next, err := bag.tags.page(keysPage, cursor, limit, bag.universe, bag.entity, prefix, prefix)
+	return bag.tags.unscope(keys), next, err
+}
+
+// page runs a query that lists a page of identifiers after the position
+// pointed by the cursor, and returns the cursor for the next page.
+func (tags *Tags) page(query, cursor string, limit int, args ...any) ([]string, string, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if limit <= 0 {
+		return nil, "", ErrInvalidLimit
+	}
+	ctx, cancel := tags.context(context.Background())
+	defer cancel()
+	rs, err := tags.db.QueryContext(ctx, tags.sql(query), append(args, after, limit)...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rs.Close()
+
+	result := []string{}
+	for rs.Next() {
+		var id string
+		if err := rs.Scan(&id); err != nil {
+			return nil, "", err
+		}
+		result = append(result, id)
+	}
+	if err := rs.Err(); err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(result) == limit {
+		next = encodeCursor(result[len(result)-1])
+	}
+	return result, next, nil
+}
+
+// encodeCursor returns an opaque cursor pointing to the given position.
+func encodeCursor(position string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(position))
+}
+
+// decodeCursor returns the position pointed by an opaque cursor.
+func decodeCursor(cursor string) (string, error) {
+	position, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", ErrInvalidCursor
+	}
+	return string(position), nil
+}
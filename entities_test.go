@@ -0,0 +1,140 @@
+package tango
+
+import "testing"
+
+func TestRandomEntities(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', '1', 'entered', 'true'),
+		('1234', '1', 'points', '10'),
+		('1234', '2', 'entered', 'true'),
+		('1234', '3', 'points', '5'),
+		('4321', '4', 'entered', 'true')`); err != nil {
+		t.Error(err)
+	}
+
+	result, err := tags.RandomEntities("1234", 10, "")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(result) != 3 {
+		t.Errorf("Expected 3 entities, got %v", result)
+	}
+
+	result, err = tags.RandomEntities("1234", 10, "entered")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(result) != 2 {
+		t.Errorf("Expected 2 entities, got %v", result)
+	}
+	for _, entity := range result {
+		if entity != "1" && entity != "2" {
+			t.Errorf("Unexpected entity %s", entity)
+		}
+	}
+
+	result, err = tags.RandomEntities("1234", 1, "")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(result) != 1 {
+		t.Errorf("Expected 1 entity, got %v", result)
+	}
+}
+
+func TestEntitiesPagination(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', 'e', 'points', '1'),
+		('1234', 'a', 'points', '1'),
+		('1234', 'a', 'level', '1'),
+		('1234', 'c', 'points', '1'),
+		('1234', 'b', 'points', '1'),
+		('1234', 'd', 'points', '1'),
+		('4321', 'z', 'points', '1')`); err != nil {
+		t.Error(err)
+	}
+
+	var pages [][]string
+	cursor := ""
+	for {
+		page, next, err := tags.Entities("1234", cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, page)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	expected := [][]string{{"a", "b"}, {"c", "d"}, {"e"}}
+	if len(pages) != len(expected) {
+		t.Fatalf("Expected %d pages, got %v", len(expected), pages)
+	}
+	for i, page := range expected {
+		if len(pages[i]) != len(page) {
+			t.Errorf("Expected page %d to be %v, was %v", i, page, pages[i])
+			continue
+		}
+		for j, entity := range page {
+			if pages[i][j] != entity {
+				t.Errorf("Expected page %d to be %v, was %v", i, page, pages[i])
+			}
+		}
+	}
+}
+
+func TestTagsPagination(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', '5678', 'c', '1'),
+		('1234', '5678', 'a', '1'),
+		('1234', '5678', 'b', '1')`); err != nil {
+		t.Error(err)
+	}
+
+	bag := tags.TagBag("1234", "5678")
+	page, next, err := bag.TagsPage("", 2)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(page) != 2 || page[0] != "a" || page[1] != "b" || next == "" {
+		t.Errorf("Unexpected first page %v (next %q)", page, next)
+	}
+	page, next, err = bag.TagsPage(next, 2)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(page) != 1 || page[0] != "c" || next != "" {
+		t.Errorf("Unexpected second page %v (next %q)", page, next)
+	}
+}
+
+func TestPaginationInvalidCursor(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, _, err := tags.Entities("1234", "!!!", 10); err != ErrInvalidCursor {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
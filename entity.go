@@ -0,0 +1,76 @@
+package tango
+
+// An Entity is a handle to the tags of a specific entity that provides
+// typed accessors, so that call sites can read and write values using the
+// name of the key only. Every accessor returns whether the key is set in
+// the same way Tag.Get does, and leaves the zero value otherwise.
+type Entity struct {
+	tags     *Tags
+	universe string
+	entity   string
+}
+
+// ID returns the identifier of this entity.
+func (e *Entity) ID() string {
+	return e.entity
+}
+
+// Bag returns the tagbag holding the tags of this entity.
+func (e *Entity) Bag() *TagBag {
+	return e.tags.TagBag(e.universe, e.entity)
+}
+
+// Tag returns a particular tag of this entity given the name of the tag.
+func (e *Entity) Tag(key string) *Tag {
+	return e.tags.Tag(e.universe, e.entity, key)
+}
+
+// Get puts the value of the given tag into the out variable.
+func (e *Entity) Get(key string, out any) (bool, error) {
+	return e.Tag(key).Get(out)
+}
+
+// Set changes the value of the given tag.
+func (e *Entity) Set(key string, value any) error {
+	return e.Tag(key).Set(value)
+}
+
+// Delete removes the value of the given tag.
+func (e *Entity) Delete(key string) error {
+	return e.Tag(key).Delete()
+}
+
+// String returns the value of the given tag as a string.
+func (e *Entity) String(key string) (string, bool, error) {
+	var value string
+	exists, err := e.Get(key, &value)
+	return value, exists, err
+}
+
+// Int returns the value of the given tag as an integer.
+func (e *Entity) Int(key string) (int, bool, error) {
+	var value int
+	exists, err := e.Get(key, &value)
+	return value, exists, err
+}
+
+// Float returns the value of the given tag as a floating point number.
+func (e *Entity) Float(key string) (float64, bool, error) {
+	var value float64
+	exists, err := e.Get(key, &value)
+	return value, exists, err
+}
+
+// Bool returns the value of the given tag as a boolean.
+func (e *Entity) Bool(key string) (bool, bool, error) {
+	var value bool
+	exists, err := e.Get(key, &value)
+	return value, exists, err
+}
+
+// Strings returns the value of the given tag as a list of strings.
+func (e *Entity) Strings(key string) ([]string, bool, error) {
+	var value []string
+	exists, err := e.Get(key, &value)
+	return value, exists, err
+}
@@ -0,0 +1,79 @@
+package tango
+
+import "testing"
+
+func TestEntityTypedAccessors(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', '5678', 'greeting', '"hello"'),
+		('1234', '5678', 'points', '33'),
+		('1234', '5678', 'ratio', '0.5'),
+		('1234', '5678', 'enabled', 'true'),
+		('1234', '5678', 'roles', '["admin","mod"]')`); err != nil {
+		t.Error(err)
+	}
+
+	e := tags.Entity("1234", "5678")
+	if s, ok, err := e.String("greeting"); err != nil || !ok || s != "hello" {
+		t.Errorf("Expected greeting to be hello, was %s (%v, %v)", s, ok, err)
+	}
+	if i, ok, err := e.Int("points"); err != nil || !ok || i != 33 {
+		t.Errorf("Expected points to be 33, was %d (%v, %v)", i, ok, err)
+	}
+	if f, ok, err := e.Float("ratio"); err != nil || !ok || f != 0.5 {
+		t.Errorf("Expected ratio to be 0.5, was %f (%v, %v)", f, ok, err)
+	}
+	if b, ok, err := e.Bool("enabled"); err != nil || !ok || !b {
+		t.Errorf("Expected enabled to be true, was %v (%v, %v)", b, ok, err)
+	}
+	roles, ok, err := e.Strings("roles")
+	if err != nil || !ok || len(roles) != 2 || roles[0] != "admin" || roles[1] != "mod" {
+		t.Errorf("Expected roles to be [admin mod], was %v (%v, %v)", roles, ok, err)
+	}
+}
+
+func TestEntityMissing(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	s, ok, err := tags.Entity("1234", "5678").String("greeting")
+	if err != nil {
+		t.Error(err)
+	}
+	if ok {
+		t.Errorf("Expected key not to exist")
+	}
+	if s != "" {
+		t.Errorf("Expected zero value, was %s", s)
+	}
+}
+
+func TestEntitySetDelete(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	e := tags.ForUniverse("1234").Entity("5678")
+	if err := e.Set("points", 10); err != nil {
+		t.Error(err)
+	}
+	if i, ok, err := e.Int("points"); err != nil || !ok || i != 10 {
+		t.Errorf("Expected points to be 10, was %d (%v, %v)", i, ok, err)
+	}
+	if err := e.Delete("points"); err != nil {
+		t.Error(err)
+	}
+	if _, ok, err := e.Int("points"); err != nil || ok {
+		t.Errorf("Expected points to be deleted (%v, %v)", ok, err)
+	}
+}
@@ -0,0 +1,180 @@
+package tango
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+var (
+	// ErrImmutable is returned when trying to overwrite a tag that can
+	// only be written once.
+	ErrImmutable = errors.New("tango: tag is immutable and already set")
+
+	// ErrHookPanic is returned when a callback provided by the application,
+	// such as a validator or a loader, panics while running an operation.
+	ErrHookPanic = errors.New("tango: hook panicked")
+
+	// ErrInvalidCursor is returned when a pagination cursor is malformed.
+	ErrInvalidCursor = errors.New("tango: invalid cursor")
+
+	// ErrInvalidLimit is returned when a page is requested with a limit
+	// that is not positive.
+	ErrInvalidLimit = errors.New("tango: invalid page limit")
+
+	// ErrInvalidSnapshot is returned when restoring something that is not
+	// a snapshot or that is corrupted.
+	ErrInvalidSnapshot = errors.New("tango: invalid snapshot")
+
+	// ErrChecksumMismatch is returned when restoring a snapshot whose
+	// checksums do not match its contents, or that is truncated.
+	ErrChecksumMismatch = errors.New("tango: snapshot checksum mismatch")
+
+	// ErrUnsupportedSnapshot is returned when restoring a snapshot written
+	// using a newer version of the format.
+	ErrUnsupportedSnapshot = errors.New("tango: unsupported snapshot version")
+
+	// ErrInvalidEnum is returned when setting a key constrained using
+	// WithEnum to a value that is not allowed.
+	ErrInvalidEnum = errors.New("tango: value is not one of the allowed values")
+
+	// ErrOutOfRange is returned when setting a key constrained using
+	// WithRange to a value that is not a number within the range.
+	ErrOutOfRange = errors.New("tango: value is out of range")
+
+	// ErrReferenceCycle is returned when reading a tag whose references
+	// point back to a tag of the chain.
+	ErrReferenceCycle = errors.New("tango: reference cycle")
+
+	// ErrReferenceDepth is returned when reading a tag whose chain of
+	// references is longer than the limit given to WithReferences.
+	ErrReferenceDepth = errors.New("tango: too many references")
+
+	// ErrDerivedKey is returned when trying to write a key whose value is
+	// derived from other keys using WithDerived.
+	ErrDerivedKey = errors.New("tango: key is derived and cannot be written")
+
+	// ErrConflict is returned by conditional writes when the tag has been
+	// modified since the time given by the caller, and by imports using
+	// ImportFailOnConflict when a tag already has a different value.
+	ErrConflict = errors.New("tango: tag was modified concurrently")
+
+	// ErrTrackingDisabled is returned by operations that depend on the
+	// tracking of writes or reads when the engine was not configured using
+	// WithTracking or WithReadTracking.
+	ErrTrackingDisabled = errors.New("tango: tracking is not enabled")
+
+	// ErrTypeMismatch is returned when the value of a tag does not fit
+	// into the variable it is being read into, in which case the error
+	// also wraps the error returned by the codec, or when setting a key
+	// declared in a Manifest to a value of a different kind.
+	ErrTypeMismatch = errors.New("tango: stored value does not match destination type")
+
+	// ErrReadOnly is returned when writing through a scope created using
+	// ScopedWith that is read only.
+	ErrReadOnly = errors.New("tango: scope is read only")
+
+	// ErrQuotaExceeded is returned when writing a new tag through a scope
+	// created using ScopedWith that already holds as many tags as allowed.
+	ErrQuotaExceeded = errors.New("tango: scope quota exceeded")
+
+	// ErrScoped is returned when calling a maintenance operation that
+	// works over the whole store, such as an export or a bulk upsert, on
+	// an engine returned by Scoped.
+	ErrScoped = errors.New("tango: operation is not available in a scope")
+
+	// ErrDecoratedStore is returned by the operations that cannot be
+	// expressed using the methods of a TagStore when the engine was
+	// configured using WithStore, since they would bypass the decorators.
+	ErrDecoratedStore = errors.New("tango: operation is not supported by decorated stores")
+
+	// ErrWatchOverflow is returned by Subscription.Err when the
+	// subscription was closed because the subscriber did not keep up.
+	ErrWatchOverflow = errors.New("tango: watch subscription overflowed")
+
+	// ErrUnknownUniverse is returned when operating over a universe that
+	// is not allowed by WithUniverses or WithUniverseFilter.
+	ErrUnknownUniverse = errors.New("tango: universe is not allowed")
+)
+
+// An Error is returned when an operation over a tag fails. It carries the
+// operation and the full key of the tag, and it wraps the original error,
+// so that errors.Is and errors.As can still be used to inspect it.
+type Error struct {
+	Op       string
+	Universe string
+	Entity   string
+	Key      string
+	Err      error
+
+	retryable bool
+}
+
+// Error returns a description of the error including the key of the tag.
+func (e *Error) Error() string {
+	return fmt.Sprintf("tango: %s %s/%s/%s: %v", e.Op, e.Universe, e.Entity, e.Key, e.Err)
+}
+
+// Unwrap returns the original error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable returns whether the operation failed because of a transient
+// condition, such as the database being busy or a timeout, so that it may
+// succeed if it is retried.
+func (e *Error) IsRetryable() bool {
+	return e.retryable
+}
+
+// IsRetryable returns whether the error is an Error that may succeed if the
+// operation is retried. It returns false for any other error.
+func IsRetryable(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.IsRetryable()
+}
+
+// wrapError wraps the error that happened while running an operation over a
+// tag into an Error, classifying whether it is retryable.
+func (tags *Tags) wrapError(op string, tag *Tag, err error) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	retryable := isTransient(err)
+	if !retryable && tags.classifier != nil {
+		tags.notifyHook("retry classifier", func() {
+			retryable = tags.classifier(err)
+		})
+	}
+	return &Error{
+		Op:        op,
+		Universe:  tag.universe,
+		Entity:    tag.entity,
+		Key:       tag.key,
+		Err:       err,
+		retryable: retryable,
+	}
+}
+
+// isTransient returns whether the error is known to be transient, either
+// because it is a timeout or because SQLite reported that the database is
+// busy or locked by another connection.
+func isTransient(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked) {
+		return true
+	}
+	var temporary interface{ Temporary() bool }
+	if errors.As(err, &temporary) && temporary.Temporary() {
+		return true
+	}
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
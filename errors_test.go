@@ -0,0 +1,150 @@
+package tango
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestErrorWrapsKey(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	errInvalid := errors.New("invalid")
+	tags := NewTagsEngine(db, WithValidator(func(key string, value any, old json.RawMessage) (any, error) {
+		return nil, errInvalid
+	}))
+
+	err = tags.Tag("1234", "5678", "prefix").Set("!")
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("Expected an *Error, got %T", err)
+	}
+	if e.Op != "set" || e.Universe != "1234" || e.Entity != "5678" || e.Key != "prefix" {
+		t.Errorf("Unexpected error %+v", e)
+	}
+	if !errors.Is(err, errInvalid) {
+		t.Errorf("Expected error to wrap the validator error")
+	}
+	if e.IsRetryable() || IsRetryable(err) {
+		t.Errorf("Expected validation error not to be retryable")
+	}
+	if err.Error() != "tango: set 1234/5678/prefix: invalid" {
+		t.Errorf("Unexpected message %s", err.Error())
+	}
+}
+
+func TestErrorRetryableTimeout(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithTimeout(time.Nanosecond))
+
+	time.Sleep(time.Millisecond)
+	err = tags.Tag("1234", "5678", "prefix").Set("!")
+	if err == nil {
+		t.Skip("operation finished before the timeout")
+	}
+	if !IsRetryable(err) {
+		t.Errorf("Expected timeout to be retryable, got %v", err)
+	}
+}
+
+func TestErrorRetryClassifier(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	errBusy := errors.New("busy")
+	tags := NewTagsEngine(db,
+		WithValidator(func(key string, value any, old json.RawMessage) (any, error) {
+			return nil, errBusy
+		}),
+		WithRetryClassifier(func(err error) bool {
+			return errors.Is(err, errBusy)
+		}))
+
+	if err := tags.Tag("1234", "5678", "prefix").Set("!"); !IsRetryable(err) {
+		t.Errorf("Expected classified error to be retryable, got %v", err)
+	}
+}
+
+func TestErrorTypeMismatch(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', '5678', 'points', '33')`); err != nil {
+		t.Error(err)
+	}
+	var result string
+	_, err = tags.Tag("1234", "5678", "points").Get(&result)
+	if !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expected ErrTypeMismatch, got %v", err)
+	}
+	var e *Error
+	if !errors.As(err, &e) || e.Key != "points" {
+		t.Errorf("Expected error to carry the key, got %v", err)
+	}
+	var jsonErr *json.UnmarshalTypeError
+	if !errors.As(err, &jsonErr) {
+		t.Errorf("Expected error to wrap the json error, got %v", err)
+	}
+
+	// Malformed values are not a type mismatch.
+	if _, err := db.Exec(`UPDATE tags SET value = '{'`); err != nil {
+		t.Error(err)
+	}
+	_, err = tags.Tag("1234", "5678", "points").Get(&result)
+	if err == nil || errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expected a decoding error, got %v", err)
+	}
+}
+
+func TestErrorRetryableBusy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "busy.db")
+	holder, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer holder.Close()
+	if _, err := holder.Exec(`
+	CREATE TABLE tags(id INTEGER PRIMARY KEY, universe TEXT, entity TEXT, key TEXT, value TEXT);
+	CREATE UNIQUE INDEX tags_id ON tags(universe, entity, key);`); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := holder.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', '5678', 'prefix', '"?"')`); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db)
+	err = tags.Tag("1234", "5678", "prefix").Set("!")
+	if err == nil {
+		t.Fatal("Expected the write to fail while the database is locked")
+	}
+	if !IsRetryable(err) {
+		t.Errorf("Expected a busy database to be retryable, got %v", err)
+	}
+}
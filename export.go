@@ -0,0 +1,173 @@
+package tango
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+var (
+	exportBag      = `SELECT key, value FROM {table} WHERE universe = ? AND entity = ? ORDER BY key`
+	exportUniverse = `SELECT entity, key, value FROM {table} WHERE universe = ? ORDER BY entity, key`
+)
+
+// An EntityDump is the JSON representation of the tags of an entity, as
+// written by Tags.WriteUniverseJSON.
+type EntityDump struct {
+	Entity string                     `json:"entity"`
+	Tags   map[string]json.RawMessage `json:"tags"`
+}
+
+// WriteJSON writes the tags of the current tagbag into the writer as a JSON
+// object, where every key of the object is the key of a tag.
+func (bag *TagBag) WriteJSON(w io.Writer) error {
+	if err := bag.tags.checkUndecorated(); err != nil {
+		return err
+	}
+	ctx, cancel := bag.tags.context(context.Background())
+	defer cancel()
+	values, err := bag.values(ctx)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(values)
+}
+
+// values returns the JSON representation of every tag of the tagbag.
+func (bag *TagBag) values(ctx context.Context) (map[string]json.RawMessage, error) {
+	rs, err := bag.tags.db.QueryContext(ctx, bag.tags.sql(exportBag), bag.universe, bag.entity)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	values := make(map[string]json.RawMessage)
+	for rs.Next() {
+		var key, value string
+		if err := rs.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(key, bag.tags.keyPrefix) {
+			values[strings.TrimPrefix(key, bag.tags.keyPrefix)] = json.RawMessage(value)
+		}
+	}
+	return values, rs.Err()
+}
+
+// A UniverseDump is the JSON representation of the label of a universe, as
+// written by Tags.WriteUniverseJSON.
+type UniverseDump struct {
+	Universe string `json:"universe"`
+	Label    Label  `json:"label"`
+}
+
+// WriteUniverseJSON writes the tags of every entity of the given universe
+// into the writer. Every entity is written as a separate JSON object with
+// the shape of an EntityDump, followed by a newline. Entities are streamed
+// as they are read from the database, so only the tags of one entity are
+// kept in memory at the same time. If the universe has a label, it is
+// written first as a JSON object with the shape of a UniverseDump.
+func (tags *Tags) WriteUniverseJSON(ctx context.Context, universe string, w io.Writer) error {
+	if err := tags.checkUnscoped(); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	raw, labelled, err := tags.Tag(LabelsUniverse, universe, labelKey).fetch(ctx)
+	if err != nil {
+		return err
+	}
+	if labelled {
+		dump := UniverseDump{Universe: universe}
+		if err := json.Unmarshal([]byte(raw), &dump.Label); err != nil {
+			return err
+		}
+		if err := enc.Encode(dump); err != nil {
+			return err
+		}
+	}
+
+	rs, err := tags.db.QueryContext(ctx, tags.sql(exportUniverse), universe)
+	if err != nil {
+		return err
+	}
+	defer rs.Close()
+
+	var current *EntityDump
+	for rs.Next() {
+		var entity, key, value string
+		if err := rs.Scan(&entity, &key, &value); err != nil {
+			return err
+		}
+		if current != nil && current.Entity != entity {
+			if err := enc.Encode(current); err != nil {
+				return err
+			}
+			current = nil
+		}
+		if current == nil {
+			current = &EntityDump{Entity: entity, Tags: make(map[string]json.RawMessage)}
+		}
+		current.Tags[key] = json.RawMessage(value)
+	}
+	if err := rs.Err(); err != nil {
+		return err
+	}
+	if current != nil {
+		return enc.Encode(current)
+	}
+	return nil
+}
+
+// ReadUniverseJSON reads tags written by WriteUniverseJSON from the reader
+// and upserts them into the given universe, which may be different from the
+// universe they were exported from, as part of a single transaction. The
+// label of the universe is also restored if present. Tags that are already
+// set are handled according to the strategy given in the options, and the
+// returned summary tells what was done. As with Restore, hooks are not
+// called and summaries are not updated.
+func (tags *Tags) ReadUniverseJSON(ctx context.Context, universe string, r io.Reader, opts ImportOptions) (ImportSummary, error) {
+	if err := tags.checkUnscoped(); err != nil {
+		return ImportSummary{}, err
+	}
+	dec := json.NewDecoder(r)
+	var summary ImportSummary
+	err := tags.transaction(ctx, func(tx *txn) error {
+		im, err := tags.newImporter(tx, opts)
+		if err != nil {
+			return err
+		}
+		defer im.close()
+		defer func() { summary = im.summary }()
+		for {
+			var line struct {
+				EntityDump
+				Label *Label `json:"label"`
+			}
+			if err := dec.Decode(&line); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if line.Label != nil {
+				label, err := json.Marshal(line.Label)
+				if err != nil {
+					return err
+				}
+				if err := im.write(LabelsUniverse, universe, labelKey, label); err != nil {
+					return err
+				}
+			}
+			for key, value := range line.Tags {
+				if err := im.write(universe, line.Entity, key, value); err != nil {
+					return err
+				}
+			}
+		}
+	})
+	if err != nil {
+		return ImportSummary{}, err
+	}
+	tags.cache.clear()
+	return summary, nil
+}
@@ -0,0 +1,104 @@
+package tango
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestTagBagWriteJSON(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', '5678', 'string', '"hello"'),
+		('1234', '5678', 'number', '14'),
+		('1234', '9999', 'number', '1')`); err != nil {
+		t.Error(err)
+	}
+
+	var buf bytes.Buffer
+	if err := tags.TagBag("1234", "5678").WriteJSON(&buf); err != nil {
+		t.Error(err)
+	}
+	expected := `{"number":14,"string":"hello"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, buf.String())
+	}
+}
+
+func TestWriteUniverseJSON(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', 'b', 'points', '2'),
+		('1234', 'a', 'points', '1'),
+		('1234', 'a', 'name', '"john"'),
+		('4321', 'c', 'points', '3')`); err != nil {
+		t.Error(err)
+	}
+
+	var buf bytes.Buffer
+	if err := tags.WriteUniverseJSON(context.Background(), "1234", &buf); err != nil {
+		t.Error(err)
+	}
+	expected := `{"entity":"a","tags":{"name":"john","points":1}}` + "\n" +
+		`{"entity":"b","tags":{"points":2}}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := tags.WriteUniverseJSON(context.Background(), "0000", &buf); err != nil {
+		t.Error(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected empty output, got %s", buf.String())
+	}
+}
+
+func TestUniverseJSONLabels(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if err := tags.LabelUniverse("1234", Label{Name: "makigas"}); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "5678", "points").Set(10); err != nil {
+		t.Error(err)
+	}
+	var buf bytes.Buffer
+	if err := tags.WriteUniverseJSON(context.Background(), "1234", &buf); err != nil {
+		t.Error(err)
+	}
+	expected := `{"universe":"1234","label":{"name":"makigas"}}` + "\n" +
+		`{"entity":"5678","tags":{"points":10}}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, buf.String())
+	}
+
+	if _, err := tags.ReadUniverseJSON(context.Background(), "4321", &buf, ImportOptions{}); err != nil {
+		t.Error(err)
+	}
+	label, exists, err := tags.LookupLabel("4321")
+	if err != nil {
+		t.Error(err)
+	}
+	if !exists || label.Name != "makigas" {
+		t.Errorf("Expected label to be restored, was %+v (%v)", label, exists)
+	}
+	var points int
+	if _, err := tags.Tag("4321", "5678", "points").Get(&points); err != nil || points != 10 {
+		t.Errorf("Expected points to be restored, was %d (%v)", points, err)
+	}
+}
@@ -0,0 +1,64 @@
+package tango
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// A Format is a way of rendering a tagbag for humans using TagBag.Format.
+type Format int
+
+const (
+	// FormatText renders every tag in a separate line, with the keys and
+	// the values aligned in two columns.
+	FormatText Format = iota
+
+	// FormatJSON renders the tags as an indented JSON object.
+	FormatJSON
+)
+
+// Format renders the tags of the tagbag into the writer, sorted by key, for
+// display purposes such as a "show config" command. The values of the keys
+// configured using WithSensitiveKeys are masked. Use WriteJSON instead to
+// export the actual values.
+func (bag *TagBag) Format(w io.Writer, format Format) error {
+	if err := bag.tags.checkUndecorated(); err != nil {
+		return err
+	}
+	ctx, cancel := bag.tags.context(context.Background())
+	defer cancel()
+	values, err := bag.values(ctx)
+	if err != nil {
+		return err
+	}
+	for key := range values {
+		if bag.tags.sensitive[key] || bag.tags.sensitive[bag.Tag(key).key] {
+			values[key] = json.RawMessage(`"` + redacted + `"`)
+		}
+	}
+
+	switch format {
+	case FormatText:
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, key := range keys {
+			if _, err := fmt.Fprintf(tw, "%s\t%s\n", key, values[key]); err != nil {
+				return err
+			}
+		}
+		return tw.Flush()
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(values)
+	}
+	return fmt.Errorf("tango: unknown format %d", format)
+}
@@ -0,0 +1,41 @@
+package tango
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTagBagFormat(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithSensitiveKeys("token"))
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', '5678', 'prefix', '"!"'),
+		('1234', '5678', 'token', '"s3cr3t"'),
+		('1234', '5678', 'max_warnings', '3')`); err != nil {
+		t.Error(err)
+	}
+	bag := tags.TagBag("1234", "5678")
+
+	var text bytes.Buffer
+	if err := bag.Format(&text, FormatText); err != nil {
+		t.Error(err)
+	}
+	expected := "max_warnings  3\nprefix        \"!\"\ntoken         \"[redacted]\"\n"
+	if text.String() != expected {
+		t.Errorf("Unexpected text output %q", text.String())
+	}
+
+	var js bytes.Buffer
+	if err := bag.Format(&js, FormatJSON); err != nil {
+		t.Error(err)
+	}
+	expected = "{\n  \"max_warnings\": 3,\n  \"prefix\": \"!\",\n  \"token\": \"[redacted]\"\n}\n"
+	if js.String() != expected {
+		t.Errorf("Unexpected JSON output %q", js.String())
+	}
+}
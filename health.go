@@ -0,0 +1,46 @@
+package tango
+
+import (
+	"context"
+	"fmt"
+)
+
+var (
+	pingTags       = `SELECT universe, entity, key, value FROM {table} LIMIT 0`
+	pingTracking   = `SELECT updated_at, updated_by FROM {table} LIMIT 0`
+	pingReads      = `SELECT read_at FROM {table} LIMIT 0`
+	pingTombstones = `SELECT universe, entity, key, deleted_at FROM {table}_tombstones LIMIT 0`
+	pingSummaries  = `SELECT universe, name, value FROM {table}_summaries LIMIT 0`
+)
+
+// Ping checks that the database is reachable and that the tables required
+// by the features enabled in the engine exist, so that services can report
+// whether the engine is ready before accepting traffic.
+func (tags *Tags) Ping(ctx context.Context) error {
+	ctx, cancel := tags.context(ctx)
+	defer cancel()
+	if err := tags.db.PingContext(ctx); err != nil {
+		return err
+	}
+	checks := []string{pingTags}
+	if tags.tracking {
+		checks = append(checks, pingTracking)
+	}
+	if tags.tombstones {
+		checks = append(checks, pingTombstones)
+	}
+	if tags.readSampleRate > 0 {
+		checks = append(checks, pingReads)
+	}
+	if len(tags.summaries) > 0 {
+		checks = append(checks, pingSummaries)
+	}
+	for _, check := range checks {
+		rs, err := tags.db.QueryContext(ctx, tags.sql(check))
+		if err != nil {
+			return fmt.Errorf("tango: schema check failed: %w", err)
+		}
+		rs.Close()
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package tango
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if err := tags.Ping(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if err := NewTagsEngine(db, WithTracking(), WithSummary("points", "points", Sum)).Ping(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if err := NewTagsEngine(db, WithTable("missing")).Ping(context.Background()); err == nil {
+		t.Errorf("Expected missing table to fail the check")
+	}
+}
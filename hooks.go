@@ -0,0 +1,29 @@
+package tango
+
+import "fmt"
+
+// callHook runs a callback provided by the application, such as a validator
+// or a loader, recovering from any panic. A panic is converted into an error
+// wrapping ErrHookPanic, so that the operation that called the hook fails
+// and its transaction is rolled back instead of crashing the program.
+func (tags *Tags) callHook(name string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %s: %v", ErrHookPanic, name, r)
+		}
+	}()
+	return fn()
+}
+
+// notifyHook runs a callback provided by the application that cannot make
+// the operation fail, such as a reporting function. Panics are recovered
+// and logged, if the engine has a logger.
+func (tags *Tags) notifyHook(name string, fn func()) {
+	err := tags.callHook(name, func() error {
+		fn()
+		return nil
+	})
+	if err != nil && tags.logger != nil {
+		tags.logger.Print(err)
+	}
+}
@@ -0,0 +1,84 @@
+package tango
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestHookPanicInValidator(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db,
+		WithSummary("total", "points", Sum),
+		WithValidator(func(key string, value any, old json.RawMessage) (any, error) {
+			if value == 13 {
+				panic("unlucky number")
+			}
+			return value, nil
+		}))
+
+	if err := tags.Tag("1234", "5678", "points").Set(10); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "5678", "points").Set(13); !errors.Is(err, ErrHookPanic) {
+		t.Errorf("Expected ErrHookPanic, got %v", err)
+	}
+
+	// The store should be left as it was before the failed write.
+	var result int
+	if _, err := tags.Tag("1234", "5678", "points").Get(&result); err != nil {
+		t.Error(err)
+	}
+	if result != 10 {
+		t.Errorf("Expected points to be 10, was %d", result)
+	}
+	if total, err := tags.Summary("1234", "total"); err != nil || total != 10 {
+		t.Errorf("Expected summary to be 10, was %f (%v)", total, err)
+	}
+}
+
+func TestHookPanicInLoader(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithLoader("rank", func(universe, entity string) (any, bool, error) {
+		panic("broken loader")
+	}))
+
+	var result string
+	if _, err := tags.Tag("1234", "5678", "rank").Get(&result); !errors.Is(err, ErrHookPanic) {
+		t.Errorf("Expected ErrHookPanic, got %v", err)
+	}
+}
+
+func TestHookPanicInReporter(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	var buf bytes.Buffer
+	tags := NewTagsEngine(db,
+		WithLogger(log.New(&buf, "", 0)),
+		WithDeprecatedKeys("lang"),
+		WithDeprecationHook(func(d Deprecation) {
+			panic("broken hook")
+		}))
+
+	// Reporting hooks cannot make the operation fail.
+	if err := tags.Tag("1234", "5678", "lang").Set("es"); err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(buf.String(), "broken hook") {
+		t.Errorf("Expected panic to be logged, got %q", buf.String())
+	}
+}
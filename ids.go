@@ -0,0 +1,144 @@
+package tango
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidID is returned when an identifier does not follow the rules
+// of the engine, or when decoding a malformed escaped identifier.
+var ErrInvalidID = errors.New("tango: invalid identifier")
+
+// storageKeySeparator separates the universe, entity and key of a tag when
+// they are flattened into a single storage key.
+const storageKeySeparator = "/"
+
+// EscapeID returns the portable form of an identifier, which can be used as
+// a universe, entity or key. Printable ASCII characters are kept, except
+// for the percent sign and the slash, and every other byte (spaces,
+// control characters and the bytes of non ASCII characters) is percent
+// encoded using uppercase hexadecimal digits. Colons are kept, since they
+// are used to namespace identifiers, such as "telegram:1234".
+func EscapeID(id string) string {
+	var b strings.Builder
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if c <= ' ' || c >= 0x7f || c == '%' || c == '/' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// UnescapeID returns the identifier whose portable form is given, failing
+// with ErrInvalidID if it is not well formed.
+func UnescapeID(escaped string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(escaped); i++ {
+		c := escaped[i]
+		if c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+		if i+2 >= len(escaped) || !isHex(escaped[i+1]) || !isHex(escaped[i+2]) {
+			return "", fmt.Errorf("%w: %q", ErrInvalidID, escaped)
+		}
+		b.WriteByte(unhex(escaped[i+1])<<4 | unhex(escaped[i+2]))
+		i += 2
+	}
+	return b.String(), nil
+}
+
+// IsPortableID returns whether the identifier is already in its portable
+// form, so that it can be used by any backend without being escaped.
+func IsPortableID(id string) bool {
+	return EscapeID(id) == id
+}
+
+// StorageKey flattens the address of a tag into a single string, such as
+// the key of a key-value store, by joining the portable form of its
+// universe, entity and key using slashes.
+func StorageKey(universe, entity, key string) string {
+	return strings.Join([]string{EscapeID(universe), EscapeID(entity), EscapeID(key)}, storageKeySeparator)
+}
+
+// ParseStorageKey returns the universe, entity and key of a storage key
+// made using StorageKey.
+func ParseStorageKey(storageKey string) (string, string, string, error) {
+	parts := strings.Split(storageKey, storageKeySeparator)
+	if len(parts) != 3 {
+		return "", "", "", fmt.Errorf("%w: %q", ErrInvalidID, storageKey)
+	}
+	for i, part := range parts {
+		id, err := UnescapeID(part)
+		if err != nil {
+			return "", "", "", err
+		}
+		parts[i] = id
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// checkIDs returns an error if the address of a tag being written does not
+// follow the rules configured for the engine.
+func (tags *Tags) checkIDs(universe, entity, key string) error {
+	if err := tags.checkAddress(universe, entity, key); err != nil {
+		return err
+	}
+	if tags.portableIDs {
+		for _, id := range []string{universe, entity, key} {
+			if !IsPortableID(id) {
+				return fmt.Errorf("%w: %q is not portable", ErrInvalidID, id)
+			}
+		}
+	}
+	return nil
+}
+
+// checkAddress returns an error if the universe is not allowed or if any
+// of the given identifiers is not valid in strict mode.
+func (tags *Tags) checkAddress(universe string, ids ...string) error {
+	if err := tags.checkUniverse(universe); err != nil {
+		return err
+	}
+	if !tags.strictIDs {
+		return nil
+	}
+	for _, id := range append([]string{universe}, ids...) {
+		if id == "" {
+			return fmt.Errorf("%w: empty identifier", ErrInvalidID)
+		}
+		if tags.maxIDLength > 0 && len(id) > tags.maxIDLength {
+			return fmt.Errorf("%w: %q is longer than %d bytes", ErrInvalidID, id, tags.maxIDLength)
+		}
+	}
+	return nil
+}
+
+// checkUniverse returns ErrUnknownUniverse if the engine is restricted to
+// a set of universes that does not include the given one. The universe
+// holding the labels is always allowed.
+func (tags *Tags) checkUniverse(universe string) error {
+	if tags.universeFilter == nil || universe == LabelsUniverse || tags.universeFilter(universe) {
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownUniverse, universe)
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'A' <= c && c <= 'F' || 'a' <= c && c <= 'f'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10
+	default:
+		return c - 'a' + 10
+	}
+}
@@ -0,0 +1,152 @@
+package tango
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEscapeID(t *testing.T) {
+	for id, escaped := range map[string]string{
+		"telegram:1234": "telegram:1234",
+		"a b/c%d":       "a%20b%2Fc%25d",
+		"ñ":             "%C3%B1",
+		"":              "",
+	} {
+		if got := EscapeID(id); got != escaped {
+			t.Errorf("Expected %q to escape to %q, was %q", id, escaped, got)
+		}
+		if got, err := UnescapeID(escaped); err != nil || got != id {
+			t.Errorf("Expected %q to unescape to %q, was %q (%v)", escaped, id, got, err)
+		}
+		if IsPortableID(id) != (id == escaped) {
+			t.Errorf("Unexpected portability of %q", id)
+		}
+	}
+	for _, escaped := range []string{"%", "%2", "%zz"} {
+		if _, err := UnescapeID(escaped); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("Expected %q to fail with ErrInvalidID, got %v", escaped, err)
+		}
+	}
+}
+
+func TestStorageKey(t *testing.T) {
+	key := StorageKey("telegram:-100", "a/b", "key")
+	if key != "telegram:-100/a%2Fb/key" {
+		t.Errorf("Unexpected storage key %q", key)
+	}
+	universe, entity, k, err := ParseStorageKey(key)
+	if err != nil || universe != "telegram:-100" || entity != "a/b" || k != "key" {
+		t.Errorf("Unexpected address %q %q %q (%v)", universe, entity, k, err)
+	}
+	if _, _, _, err := ParseStorageKey("a/b"); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Expected ErrInvalidID, got %v", err)
+	}
+}
+
+func TestPortableIDs(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithPortableIDs())
+
+	if err := tags.Tag("telegram:1234", "5678", "string").Set("hello"); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "john doe", "string").Set("hello"); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Expected ErrInvalidID, got %v", err)
+	}
+}
+
+func TestUniverseAllowlist(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithUniverses("1234"))
+
+	if err := tags.Tag("1234", "5678", "string").Set("hello"); err != nil {
+		t.Error(err)
+	}
+	if err := tags.LabelUniverse("1234", Label{Name: "Guild"}); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("", "5678", "string").Set("hello"); !errors.Is(err, ErrUnknownUniverse) {
+		t.Errorf("Expected ErrUnknownUniverse, got %v", err)
+	}
+	var result string
+	if _, err := tags.Tag("4321", "5678", "string").Get(&result); !errors.Is(err, ErrUnknownUniverse) {
+		t.Errorf("Expected ErrUnknownUniverse, got %v", err)
+	}
+	if err := tags.Tag("4321", "5678", "string").Delete(); !errors.Is(err, ErrUnknownUniverse) {
+		t.Errorf("Expected ErrUnknownUniverse, got %v", err)
+	}
+	if _, err := tags.TagBag("4321", "5678").Tags(); !errors.Is(err, ErrUnknownUniverse) {
+		t.Errorf("Expected ErrUnknownUniverse, got %v", err)
+	}
+
+	// The bulk operations cannot be used to bypass the allowlist.
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('4321', '5678', 'string', '"hello"')`); err != nil {
+		t.Error(err)
+	}
+	other := tags.TagBag("4321", "5678")
+	if _, err := other.DeletePrefix(""); !errors.Is(err, ErrUnknownUniverse) {
+		t.Errorf("Expected ErrUnknownUniverse, got %v", err)
+	}
+	if _, err := other.HasMany([]string{"string"}); !errors.Is(err, ErrUnknownUniverse) {
+		t.Errorf("Expected ErrUnknownUniverse, got %v", err)
+	}
+	if _, _, err := other.TagsPage("", 10); !errors.Is(err, ErrUnknownUniverse) {
+		t.Errorf("Expected ErrUnknownUniverse, got %v", err)
+	}
+	if _, _, err := tags.Entities("4321", "", 10); !errors.Is(err, ErrUnknownUniverse) {
+		t.Errorf("Expected ErrUnknownUniverse, got %v", err)
+	}
+	if _, err := tags.RandomEntities("4321", 1, ""); !errors.Is(err, ErrUnknownUniverse) {
+		t.Errorf("Expected ErrUnknownUniverse, got %v", err)
+	}
+	if _, _, err := other.Tag("string").Metadata(); !errors.Is(err, ErrUnknownUniverse) {
+		t.Errorf("Expected ErrUnknownUniverse, got %v", err)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM tags WHERE universe = '4321'`).Scan(&count); err != nil || count != 1 {
+		t.Errorf("Expected the tags of 4321 to be kept, got %d (%v)", count, err)
+	}
+}
+
+func TestStrictIDs(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	// By default, empty identifiers are accepted.
+	legacy := NewTagsEngine(db)
+	if err := legacy.Tag("", "5678", "string").Set("hello"); err != nil {
+		t.Error(err)
+	}
+
+	tags := NewTagsEngine(db, WithStrictIDs(8))
+	if err := tags.Tag("1234", "5678", "string").Set("hello"); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("", "5678", "string").Set("hello"); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Expected ErrInvalidID, got %v", err)
+	}
+	var result string
+	if _, err := tags.Tag("1234", "", "string").Get(&result); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Expected ErrInvalidID, got %v", err)
+	}
+	if err := tags.Tag("1234", "5678", "").Delete(); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Expected ErrInvalidID, got %v", err)
+	}
+	if _, err := tags.TagBag("1234", "").Tags(); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Expected ErrInvalidID, got %v", err)
+	}
+	if err := tags.Tag("1234", "5678", "too long key").Set("hello"); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Expected ErrInvalidID, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package tango
+
+import "context"
+
+// SetOnce sets the value of the tag only if the tag is not set yet. If the
+// tag already holds a value, even if it is null, it will fail with
+// ErrImmutable and the stored value will remain unchanged.
+func (tag *Tag) SetOnce(value any) error {
+	tag.warnDeprecated("set")
+	if err := tag.tags.checkUndecorated(); err != nil {
+		return tag.tags.failed("set", tag, err)
+	}
+	ctx, cancel := tag.tags.context(context.Background())
+	defer cancel()
+	err := tag.tags.transaction(ctx, func(tx *txn) error {
+		if err := tag.checkUnset(tx); err != nil {
+			return err
+		}
+		return tag.setTx(tx, value)
+	})
+	return tag.tags.finish("set", tag, err)
+}
+
+// SetIfAbsent sets the value of the tag only if the tag is not set yet, and
+// reports whether it was set. It works like SetOnce, but a tag that already
+// holds a value is not an error, which makes it suitable for first writer
+// wins races, such as claiming a name.
+func (tag *Tag) SetIfAbsent(value any) (bool, error) {
+	tag.warnDeprecated("set")
+	if err := tag.tags.checkUndecorated(); err != nil {
+		return false, tag.tags.failed("set", tag, err)
+	}
+	ctx, cancel := tag.tags.context(context.Background())
+	defer cancel()
+	var won bool
+	err := tag.tags.transaction(ctx, func(tx *txn) error {
+		_, exists, err := tag.fetchTx(tx)
+		if err != nil || exists {
+			return err
+		}
+		won, err = tag.insertTx(tx, value)
+		return err
+	})
+	if err := tag.tags.finish("set", tag, err); err != nil {
+		return false, err
+	}
+	return won, nil
+}
+
+// insertTx validates and persists the value of the tag as part of the given
+// transaction, unless the tag is already set, and reports whether it was
+// stored. Setting null when the engine deletes null values stores nothing.
+func (tag *Tag) insertTx(tx *txn, value any) (bool, error) {
+	rawJson, err := tag.encodeTx(tx, value)
+	if err != nil || (rawJson == "null" && tag.tags.nullAsDelete) {
+		return false, err
+	}
+	if err := tag.constrain([]byte(rawJson)); err != nil {
+		return false, err
+	}
+	if err := tag.checkQuota(tx); err != nil {
+		return false, err
+	}
+	inserted, err := tag.insert(tx, rawJson)
+	if err != nil || !inserted {
+		return false, err
+	}
+	if err := tag.summarizeChange(tx, "", false, rawJson, true); err != nil {
+		return false, err
+	}
+	tx.written(tag, rawJson, true)
+	return true, nil
+}
+
+// checkUnset returns ErrImmutable if the tag is already set, either under
+// its key or under a legacy alias of the key.
+func (tag *Tag) checkUnset(tx *txn) error {
+	_, exists, err := tag.fetchTx(tx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrImmutable
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package tango
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTagsSetOnce(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	tag := tags.Tag("1234", "5678", "referral")
+	if err := tag.SetOnce("ABCD"); err != nil {
+		t.Error(err)
+	}
+	if err := tag.SetOnce("EFGH"); !errors.Is(err, ErrImmutable) {
+		t.Errorf("Expected ErrImmutable, got %v", err)
+	}
+	var result string
+	if _, err := tag.Get(&result); err != nil {
+		t.Error(err)
+	}
+	if result != "ABCD" {
+		t.Errorf("Expected key to resolve to 'ABCD', was `%s`", result)
+	}
+}
+
+func TestTagsImmutableKeys(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithImmutableKeys("created_at"))
+
+	tag := tags.Tag("1234", "5678", "created_at")
+	if err := tag.Set(1000); err != nil {
+		t.Error(err)
+	}
+	if err := tag.Set(2000); !errors.Is(err, ErrImmutable) {
+		t.Errorf("Expected ErrImmutable, got %v", err)
+	}
+	var result int
+	if _, err := tag.Get(&result); err != nil {
+		t.Error(err)
+	}
+	if result != 1000 {
+		t.Errorf("Expected key to resolve to 1000, was %d", result)
+	}
+
+	// Other keys are not affected.
+	other := tags.Tag("1234", "5678", "points")
+	if err := other.Set(1); err != nil {
+		t.Error(err)
+	}
+	if err := other.Set(2); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTagsSetIfAbsent(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tag := tags.Tag("1234", "names", "makigas")
+
+	if won, err := tag.SetIfAbsent("user-1"); err != nil || !won {
+		t.Errorf("Expected the first writer to win (%v, %v)", won, err)
+	}
+	if won, err := tag.SetIfAbsent("user-2"); err != nil || won {
+		t.Errorf("Expected the second writer to lose (%v, %v)", won, err)
+	}
+	var owner string
+	if _, err := tag.Get(&owner); err != nil || owner != "user-1" {
+		t.Errorf("Expected the first value to be kept, got %s (%v)", owner, err)
+	}
+	if err := tags.Tag("1234", "names", "null").Set(nil); err != nil {
+		t.Error(err)
+	}
+	if won, err := tags.Tag("1234", "names", "null").SetIfAbsent("user-3"); err != nil || won {
+		t.Errorf("Expected a null value to count as set (%v, %v)", won, err)
+	}
+}
+
+func TestTagsSetIfAbsentLegacy(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithAlias("pfx", "prefix"))
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', '5678', 'pfx', '"!"')`); err != nil {
+		t.Error(err)
+	}
+	if won, err := tags.Tag("1234", "5678", "prefix").SetIfAbsent("?"); err != nil || won {
+		t.Errorf("Expected data stored under the legacy key to count as set (%v, %v)", won, err)
+	}
+	var prefix string
+	if _, err := tags.Tag("1234", "5678", "prefix").Get(&prefix); err != nil || prefix != "!" {
+		t.Errorf("Expected the legacy value to be kept, got %s (%v)", prefix, err)
+	}
+}
+
+func TestTagsSetOnceLegacy(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithAlias("pfx", "prefix"), WithImmutableKeys("prefix"))
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', '5678', 'pfx', '"!"')`); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "5678", "prefix").SetOnce("?"); !errors.Is(err, ErrImmutable) {
+		t.Errorf("Expected ErrImmutable for data stored under the legacy key, got %v", err)
+	}
+	if err := tags.Tag("1234", "5678", "prefix").Set("?"); !errors.Is(err, ErrImmutable) {
+		t.Errorf("Expected ErrImmutable for an immutable key, got %v", err)
+	}
+	var prefix string
+	if _, err := tags.Tag("1234", "5678", "prefix").Get(&prefix); err != nil || prefix != "!" {
+		t.Errorf("Expected the legacy value to be kept, got %s (%v)", prefix, err)
+	}
+}
@@ -0,0 +1,152 @@
+package tango
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// An ImportStrategy tells what to do when an imported tag is already set.
+type ImportStrategy int
+
+const (
+	// ImportOverwrite replaces the current value with the imported one.
+	ImportOverwrite ImportStrategy = iota
+
+	// ImportSkipExisting keeps the current value.
+	ImportSkipExisting
+
+	// ImportFailOnConflict makes the import fail with ErrConflict if the
+	// current value is different from the imported one, rolling back the
+	// whole import.
+	ImportFailOnConflict
+
+	// ImportMerge merges the keys of the imported object into the current
+	// object, keeping the keys of the current object that are not in the
+	// imported one. Values that are not objects are overwritten.
+	ImportMerge
+)
+
+// ImportOptions tunes the operations that import tags into the store.
+type ImportOptions struct {
+	// Strategy is what to do with the tags that are already set. By
+	// default, they are overwritten.
+	Strategy ImportStrategy
+
+	// NoVerify skips the verification of the checksums of a snapshot,
+	// which allows to recover what is left of a damaged snapshot.
+	NoVerify bool
+
+	// EncryptionKey is the key used to decrypt an encrypted snapshot.
+	EncryptionKey []byte
+}
+
+// An ImportSummary counts what an import has done with every record.
+type ImportSummary struct {
+	// Inserted is the number of tags that were not set before.
+	Inserted int
+
+	// Overwritten is the number of tags whose value was replaced.
+	Overwritten int
+
+	// Merged is the number of tags whose object was merged.
+	Merged int
+
+	// Skipped is the number of tags that were left untouched, either
+	// because they were already set or because they already had the
+	// imported value.
+	Skipped int
+
+	// Deleted is the number of tags removed by ApplyChanges.
+	Deleted int
+}
+
+// An importer writes imported tags as part of a transaction, following the
+// strategy given in the options, and counts what it did.
+type importer struct {
+	tags     *Tags
+	tx       *txn
+	stmt     *sql.Stmt
+	strategy ImportStrategy
+	summary  ImportSummary
+}
+
+func (tags *Tags) newImporter(tx *txn, opts ImportOptions) (*importer, error) {
+	stmt, err := tx.PrepareContext(tx.ctx, tags.sql(tagUpsert))
+	if err != nil {
+		return nil, err
+	}
+	return &importer{tags: tags, tx: tx, stmt: stmt, strategy: opts.Strategy}, nil
+}
+
+func (im *importer) close() error {
+	return im.stmt.Close()
+}
+
+// write imports the given value of a tag. The key is written as given,
+// without resolving aliases.
+func (im *importer) write(universe, entity, key string, value json.RawMessage) error {
+	tag := &Tag{tags: im.tags, universe: universe, entity: entity, key: key, name: key}
+	current, exists, err := tag.fetchKey(im.tx.ctx, im.tx, tag.key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		im.summary.Inserted++
+		return im.upsert(tag, value)
+	}
+	switch im.strategy {
+	case ImportSkipExisting:
+		im.summary.Skipped++
+		return nil
+	case ImportFailOnConflict:
+		if !sameJSON(json.RawMessage(current), value) {
+			return fmt.Errorf("%w: %s/%s/%s", ErrConflict, tag.universe, tag.entity, tag.key)
+		}
+		im.summary.Skipped++
+		return nil
+	case ImportMerge:
+		if merged, ok := mergeObjects(json.RawMessage(current), value); ok {
+			im.summary.Merged++
+			return im.upsert(tag, merged)
+		}
+	}
+	im.summary.Overwritten++
+	return im.upsert(tag, value)
+}
+
+func (im *importer) upsert(tag *Tag, value json.RawMessage) error {
+	if _, err := im.stmt.ExecContext(im.tx.ctx, tag.universe, tag.entity, tag.key, string(value)); err != nil {
+		return err
+	}
+	tag.tags.bloom.add(tag.universe, tag.entity, tag.key)
+	return nil
+}
+
+// delete removes a tag, given its key as stored.
+func (im *importer) delete(universe, entity, key string) error {
+	result, err := im.tx.ExecContext(im.tx.ctx, im.tags.sql(tagDelete), universe, entity, key)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	im.summary.Deleted += int(affected)
+	return err
+}
+
+// mergeObjects merges the keys of the imported object into the current
+// object, and returns false if any of them is not an object.
+func mergeObjects(current, imported json.RawMessage) (json.RawMessage, bool) {
+	var base, patch map[string]json.RawMessage
+	if json.Unmarshal(current, &base) != nil || json.Unmarshal(imported, &patch) != nil || base == nil || patch == nil {
+		return nil, false
+	}
+	for key, value := range patch {
+		base[key] = value
+	}
+	merged, err := json.Marshal(base)
+	if err != nil {
+		return nil, false
+	}
+	return merged, true
+}
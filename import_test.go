@@ -0,0 +1,79 @@
+package tango
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestImportStrategies(t *testing.T) {
+	dump := `{"entity":"5678","tags":{"string":"bye","object":{"b":2},"same":1,"new":true}}`
+	cases := []struct {
+		strategy ImportStrategy
+		summary  ImportSummary
+		string   string
+		object   string
+	}{
+		{ImportOverwrite, ImportSummary{Inserted: 1, Overwritten: 3}, `"bye"`, `{"b":2}`},
+		{ImportSkipExisting, ImportSummary{Inserted: 1, Skipped: 3}, `"hello"`, `{"a":1}`},
+		{ImportMerge, ImportSummary{Inserted: 1, Overwritten: 2, Merged: 1}, `"bye"`, `{"a":1,"b":2}`},
+	}
+	for _, c := range cases {
+		db, tags, err := prepareTagEngine()
+		if err != nil {
+			t.Error(err)
+		}
+		if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+			('1234', '5678', 'string', '"hello"'),
+			('1234', '5678', 'object', '{"a":1}'),
+			('1234', '5678', 'same', '1')`); err != nil {
+			t.Error(err)
+		}
+
+		summary, err := tags.ReadUniverseJSON(context.Background(), "1234", strings.NewReader(dump), ImportOptions{Strategy: c.strategy})
+		if err != nil {
+			t.Error(err)
+		}
+		if summary != c.summary {
+			t.Errorf("Expected summary %+v for strategy %d, was %+v", c.summary, c.strategy, summary)
+		}
+		var str, obj string
+		if err := db.QueryRow(`SELECT value FROM tags WHERE key = 'string'`).Scan(&str); err != nil || str != c.string {
+			t.Errorf("Expected string to be %s for strategy %d, was %s (%v)", c.string, c.strategy, str, err)
+		}
+		if err := db.QueryRow(`SELECT value FROM tags WHERE key = 'object'`).Scan(&obj); err != nil || obj != c.object {
+			t.Errorf("Expected object to be %s for strategy %d, was %s (%v)", c.object, c.strategy, obj, err)
+		}
+		db.Close()
+	}
+}
+
+func TestImportFailOnConflict(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', '5678', 'same', '{"a": 1}')`); err != nil {
+		t.Error(err)
+	}
+	opts := ImportOptions{Strategy: ImportFailOnConflict}
+
+	// Equal values are not conflicts.
+	dump := `{"entity":"5678","tags":{"same":{"a":1},"new":1}}`
+	summary, err := tags.ReadUniverseJSON(context.Background(), "1234", strings.NewReader(dump), opts)
+	if err != nil || summary != (ImportSummary{Inserted: 1, Skipped: 1}) {
+		t.Errorf("Unexpected summary %+v (%v)", summary, err)
+	}
+
+	// Different values make the whole import fail.
+	dump = `{"entity":"5678","tags":{"same":{"a":2},"other":1}}`
+	if _, err := tags.ReadUniverseJSON(context.Background(), "1234", strings.NewReader(dump), opts); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM tags`).Scan(&count); err != nil || count != 2 {
+		t.Errorf("Expected the import to be rolled back, found %d tags (%v)", count, err)
+	}
+}
@@ -0,0 +1,80 @@
+package tango
+
+import (
+	"context"
+	"strings"
+)
+
+// Kind is the JSON type of the value stored for a tag.
+type Kind int
+
+const (
+	// KindInvalid is returned when the value is not valid JSON.
+	KindInvalid Kind = iota
+	KindNull
+	KindBool
+	KindNumber
+	KindString
+	KindArray
+	KindObject
+)
+
+// String returns a human readable representation of the kind.
+func (kind Kind) String() string {
+	switch kind {
+	case KindNull:
+		return "null"
+	case KindBool:
+		return "bool"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindArray:
+		return "array"
+	case KindObject:
+		return "object"
+	}
+	return "invalid"
+}
+
+// MarshalText encodes the kind using its human readable representation.
+func (kind Kind) MarshalText() ([]byte, error) {
+	return []byte(kind.String()), nil
+}
+
+// Kind reports the type of the value stored for the tag without decoding
+// it, and whether the tag is set. It is useful for generic tooling that
+// needs to render values appropriately without knowing what they are.
+func (tag *Tag) Kind() (Kind, bool, error) {
+	tag.warnDeprecated("get")
+	raw, exists, err := tag.tags.store.GetTag(context.Background(), tag.universe, tag.entity, tag.name)
+	if err != nil || !exists {
+		return KindInvalid, false, err
+	}
+	return kindOf(string(raw)), true, nil
+}
+
+// kindOf returns the kind of the given JSON representation by looking at
+// its first character.
+func kindOf(raw string) Kind {
+	raw = strings.TrimLeft(raw, " \t\r\n")
+	if raw == "" {
+		return KindInvalid
+	}
+	switch c := raw[0]; {
+	case c == 'n':
+		return KindNull
+	case c == 't' || c == 'f':
+		return KindBool
+	case c == '-' || (c >= '0' && c <= '9'):
+		return KindNumber
+	case c == '"':
+		return KindString
+	case c == '[':
+		return KindArray
+	case c == '{':
+		return KindObject
+	}
+	return KindInvalid
+}
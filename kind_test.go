@@ -0,0 +1,45 @@
+package tango
+
+import "testing"
+
+func TestTagKind(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', '5678', 'string', '"hello"'),
+		('1234', '5678', 'number', '-3.5'),
+		('1234', '5678', 'bool', 'false'),
+		('1234', '5678', 'null', 'null'),
+		('1234', '5678', 'array', ' [1, 2]'),
+		('1234', '5678', 'object', '{"a": 1}')`); err != nil {
+		t.Error(err)
+	}
+	for key, want := range map[string]Kind{
+		"string": KindString,
+		"number": KindNumber,
+		"bool":   KindBool,
+		"null":   KindNull,
+		"array":  KindArray,
+		"object": KindObject,
+	} {
+		kind, exists, err := tags.Tag("1234", "5678", key).Kind()
+		if err != nil {
+			t.Error(err)
+		}
+		if !exists || kind != want {
+			t.Errorf("Expected %s to be %s, was %s (%v)", key, want, kind, exists)
+		}
+	}
+
+	kind, exists, err := tags.Tag("1234", "5678", "missing").Kind()
+	if err != nil {
+		t.Error(err)
+	}
+	if exists || kind != KindInvalid {
+		t.Errorf("Expected missing key not to exist, was %s (%v)", kind, exists)
+	}
+}
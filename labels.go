@@ -0,0 +1,29 @@
+package tango
+
+// LabelsUniverse is the reserved universe where the labels of the universes
+// are stored. Every labelled universe is an entity of this universe.
+const LabelsUniverse = "tango:labels"
+
+// labelKey is the key holding the label of a universe.
+const labelKey = "label"
+
+// A Label gives a human readable name and additional metadata to a
+// universe, so that tools can display something better than its ID.
+type Label struct {
+	Name     string            `json:"name"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// LabelUniverse sets the label of the given universe. The labels are kept
+// in the engine itself, in the LabelsUniverse universe.
+func (tags *Tags) LabelUniverse(universe string, label Label) error {
+	return tags.Tag(LabelsUniverse, universe, labelKey).Set(label)
+}
+
+// LookupLabel returns the label of the given universe and whether the
+// universe has a label.
+func (tags *Tags) LookupLabel(universe string) (Label, bool, error) {
+	var label Label
+	exists, err := tags.Tag(LabelsUniverse, universe, labelKey).Get(&label)
+	return label, exists, err
+}
@@ -0,0 +1,26 @@
+package tango
+
+import "testing"
+
+func TestTagsLabels(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, exists, err := tags.LookupLabel("1234"); err != nil || exists {
+		t.Errorf("Expected universe not to be labelled (%v, %v)", exists, err)
+	}
+	label := Label{Name: "makigas", Metadata: map[string]string{"owner": "5678"}}
+	if err := tags.LabelUniverse("1234", label); err != nil {
+		t.Error(err)
+	}
+	result, exists, err := tags.LookupLabel("1234")
+	if err != nil {
+		t.Error(err)
+	}
+	if !exists || result.Name != "makigas" || result.Metadata["owner"] != "5678" {
+		t.Errorf("Unexpected label %+v (%v)", result, exists)
+	}
+}
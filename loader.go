@@ -0,0 +1,50 @@
+package tango
+
+import "context"
+
+// A Loader computes the value of a tag that is not set yet for an entity.
+// It returns the value and true if it was able to compute it, or false if
+// the tag should remain unset. Loaded values are not trusted: they go
+// through the validators and the constraints of the key before being
+// stored, like any other write.
+type Loader func(universe, entity string) (any, bool, error)
+
+// load computes the value of this tag using the given loader and persists
+// it, returning the JSON representation of the value. Engines that cannot
+// write the tag, such as read-only scopes, return the value without
+// persisting it.
+func (tag *Tag) load(ctx context.Context, loader Loader) (string, bool, error) {
+	var value any
+	var ok bool
+	err := tag.tags.callHook("loader", func() (err error) {
+		value, ok, err = loader(tag.universe, tag.entity)
+		return err
+	})
+	if err != nil || !ok {
+		return "", false, err
+	}
+	if tag.checkWritable() != nil {
+		raw, err := tag.tags.codec.Marshal(value)
+		if err != nil {
+			return "", false, err
+		}
+		if err := tag.constrain(raw); err != nil {
+			return "", false, err
+		}
+		return string(raw), true, nil
+	}
+	var rawJson string
+	err = tag.tags.transaction(ctx, func(tx *txn) (err error) {
+		if rawJson, err = tag.encodeTx(tx, value); err != nil {
+			return err
+		}
+		if err := tag.constrain([]byte(rawJson)); err != nil {
+			return err
+		}
+		return tag.storeTx(tx, rawJson)
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return rawJson, true, nil
+}
@@ -0,0 +1,115 @@
+package tango
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestLoaderMaterializesValue(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	calls := 0
+	tags := NewTagsEngine(db, WithLoader("rank", func(universe, entity string) (any, bool, error) {
+		calls++
+		return universe + "/" + entity, true, nil
+	}))
+
+	for i := 0; i < 2; i++ {
+		var result string
+		exists, err := tags.Tag("1234", "5678", "rank").Get(&result)
+		if err != nil {
+			t.Error(err)
+		}
+		if !exists || result != "1234/5678" {
+			t.Errorf("Expected key to resolve to '1234/5678', was `%s`", result)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected loader to be called once, was called %d times", calls)
+	}
+
+	// The value should be persisted.
+	var raw string
+	if err := db.QueryRow(`SELECT value FROM tags WHERE universe = '1234' AND entity = '5678' AND key = 'rank'`).Scan(&raw); err != nil {
+		t.Error(err)
+	}
+	if raw != `"1234/5678"` {
+		t.Errorf("Did not persist loaded value, persisted %s", raw)
+	}
+}
+
+func TestLoaderWithoutValue(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	tags := NewTagsEngine(db, WithLoader("rank", func(universe, entity string) (any, bool, error) {
+		return nil, false, nil
+	}))
+
+	var result string
+	exists, err := tags.Tag("1234", "5678", "rank").Get(&result)
+	if err != nil {
+		t.Error(err)
+	}
+	if exists {
+		t.Errorf("Expected key not to exist")
+	}
+}
+
+func TestLoaderReadOnly(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithLoader("core:rank", func(universe, entity string) (any, bool, error) {
+		return "gold", true, nil
+	}))
+
+	// Read-only scopes serve the loaded value, but do not persist it.
+	var rank string
+	core := tags.ScopedWith("core:", ScopeOptions{ReadOnly: true})
+	if exists, err := core.Tag("1234", "5678", "rank").Get(&rank); err != nil || !exists || rank != "gold" {
+		t.Errorf("Expected rank to be loaded as gold, was %s (%v)", rank, err)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM tags`).Scan(&count); err != nil || count != 0 {
+		t.Errorf("Expected nothing to be persisted, got %d rows (%v)", count, err)
+	}
+}
+
+func TestLoaderValidated(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	invalid := errors.New("invalid rank")
+	tags := NewTagsEngine(db,
+		WithLoader("rank", func(universe, entity string) (any, bool, error) {
+			return "tin", true, nil
+		}),
+		WithValidator(func(key string, value any, old json.RawMessage) (any, error) {
+			if value == "tin" {
+				return nil, invalid
+			}
+			return value, nil
+		}))
+
+	var rank string
+	if _, err := tags.Tag("1234", "5678", "rank").Get(&rank); !errors.Is(err, invalid) {
+		t.Errorf("Expected the loaded value to be validated, got %v", err)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM tags`).Scan(&count); err != nil || count != 0 {
+		t.Errorf("Expected nothing to be persisted, got %d rows (%v)", count, err)
+	}
+}
@@ -0,0 +1,63 @@
+package tango
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	lockAcquire = `
+	INSERT INTO {table}_locks (name, owner, expires_at) VALUES(?, ?, ?)
+	ON CONFLICT(name) DO UPDATE SET owner=excluded.owner, expires_at=excluded.expires_at
+	WHERE {table}_locks.owner = excluded.owner OR {table}_locks.expires_at < ?
+`
+	lockRelease = `DELETE FROM {table}_locks WHERE name = ? AND owner = ?`
+)
+
+// TryLock tries to take the lock with the given name on behalf of the given
+// owner, such as the name of the replica, and reports whether it was taken.
+// Locks are kept in the store, so they are shared by every process using
+// the same database, which allows to run maintenance operations on a single
+// replica. The lock expires after the given ttl unless it is taken again by
+// the same owner, which extends it, so a lock held by a process that died
+// is eventually released. It requires the locks table described in the
+// package documentation.
+func (tags *Tags) TryLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	ctx, cancel := tags.context(ctx)
+	defer cancel()
+	now := time.Now().UTC()
+	result, err := tags.db.ExecContext(ctx, tags.sql(lockAcquire), name, owner, now.Add(ttl), now)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// Unlock releases the lock with the given name, if it is held by the given
+// owner.
+func (tags *Tags) Unlock(ctx context.Context, name, owner string) error {
+	ctx, cancel := tags.context(ctx)
+	defer cancel()
+	_, err := tags.db.ExecContext(ctx, tags.sql(lockRelease), name, owner)
+	return err
+}
+
+// WithLock runs fn only if the lock with the given name can be taken by the
+// given owner, releasing the lock afterwards, and reports whether fn was
+// run. The ttl should be longer than the time fn takes to run, or another
+// owner may take the lock while fn is still running.
+func (tags *Tags) WithLock(ctx context.Context, name, owner string, ttl time.Duration, fn func(ctx context.Context) error) (bool, error) {
+	locked, err := tags.TryLock(ctx, name, owner, ttl)
+	if err != nil || !locked {
+		return false, err
+	}
+	err = tags.callHook("lock", func() error {
+		return fn(ctx)
+	})
+	// Release the lock even if the context has been cancelled.
+	if unlockErr := tags.Unlock(context.Background(), name, owner); err == nil {
+		err = unlockErr
+	}
+	return true, err
+}
@@ -0,0 +1,86 @@
+package tango
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTryLock(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	if locked, err := tags.TryLock(ctx, "janitor", "replica-1", time.Minute); err != nil || !locked {
+		t.Errorf("Expected replica-1 to take the lock (%v, %v)", locked, err)
+	}
+	if locked, err := tags.TryLock(ctx, "janitor", "replica-2", time.Minute); err != nil || locked {
+		t.Errorf("Expected replica-2 not to take the lock (%v, %v)", locked, err)
+	}
+	if locked, err := tags.TryLock(ctx, "janitor", "replica-1", time.Minute); err != nil || !locked {
+		t.Errorf("Expected replica-1 to extend the lock (%v, %v)", locked, err)
+	}
+
+	// Only the owner can release the lock.
+	if err := tags.Unlock(ctx, "janitor", "replica-2"); err != nil {
+		t.Error(err)
+	}
+	if locked, err := tags.TryLock(ctx, "janitor", "replica-2", time.Minute); err != nil || locked {
+		t.Errorf("Expected replica-2 not to take the lock (%v, %v)", locked, err)
+	}
+	if err := tags.Unlock(ctx, "janitor", "replica-1"); err != nil {
+		t.Error(err)
+	}
+	if locked, err := tags.TryLock(ctx, "janitor", "replica-2", time.Minute); err != nil || !locked {
+		t.Errorf("Expected replica-2 to take the released lock (%v, %v)", locked, err)
+	}
+}
+
+func TestTryLockExpired(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	if locked, err := tags.TryLock(ctx, "janitor", "replica-1", -time.Second); err != nil || !locked {
+		t.Errorf("Expected replica-1 to take the lock (%v, %v)", locked, err)
+	}
+	if locked, err := tags.TryLock(ctx, "janitor", "replica-2", time.Minute); err != nil || !locked {
+		t.Errorf("Expected replica-2 to take the expired lock (%v, %v)", locked, err)
+	}
+}
+
+func TestWithLock(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	failure := errors.New("sweep failed")
+	ran, err := tags.WithLock(ctx, "janitor", "replica-1", time.Minute, func(ctx context.Context) error {
+		if locked, _ := tags.TryLock(ctx, "janitor", "replica-2", time.Minute); locked {
+			t.Errorf("Expected the lock to be held while running")
+		}
+		return failure
+	})
+	if !ran || !errors.Is(err, failure) {
+		t.Errorf("Expected the function to run and fail (%v, %v)", ran, err)
+	}
+	if locked, err := tags.TryLock(ctx, "janitor", "replica-2", time.Minute); err != nil || !locked {
+		t.Errorf("Expected the lock to be released (%v, %v)", locked, err)
+	}
+	ran, err = tags.WithLock(ctx, "janitor", "replica-1", time.Minute, func(ctx context.Context) error {
+		return nil
+	})
+	if ran || err != nil {
+		t.Errorf("Expected the function not to run (%v, %v)", ran, err)
+	}
+}
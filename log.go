@@ -0,0 +1,51 @@
+package tango
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// A Log is a view of a tag that holds a list of entries, to which new
+// entries are appended. The log is a FIFO collection, so the oldest entries
+// are dropped when new entries are appended beyond its capacity.
+type Log struct {
+	collection *Collection
+}
+
+// A LogEntry is an entry of a log, which holds the moment in which it was
+// appended and the JSON representation of its value.
+type LogEntry struct {
+	Time  time.Time       `json:"time"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Decode puts the value of the entry into the out variable.
+func (entry *LogEntry) Decode(out any) error {
+	return json.Unmarshal(entry.Value, out)
+}
+
+// Log returns a view of this tag as a log that holds at most max entries.
+// If max is zero or negative, the log will not be capped.
+func (tag *Tag) Log(max int) *Log {
+	return &Log{collection: tag.Collection(max, FIFO)}
+}
+
+// Append adds a new entry to the log, dropping the oldest entries if the
+// log grows beyond its capacity. The operation is done in a transaction,
+// so concurrent appends will not lose entries.
+func (log *Log) Append(value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return log.collection.Add(LogEntry{Time: time.Now().UTC(), Value: raw})
+}
+
+// Entries returns the entries of the log, from oldest to newest.
+func (log *Log) Entries() ([]LogEntry, error) {
+	var entries []LogEntry
+	if _, err := log.collection.Decode(&entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
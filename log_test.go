@@ -0,0 +1,55 @@
+package tango
+
+import "testing"
+
+func TestLogAppend(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	log := tags.Tag("1234", "5678", "notes").Log(3)
+	for _, note := range []string{"one", "two", "three", "four"} {
+		if err := log.Append(note); err != nil {
+			t.Error(err)
+		}
+	}
+
+	entries, err := log.Entries()
+	if err != nil {
+		t.Error(err)
+	}
+	expected := []string{"two", "three", "four"}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected log to have length %d, was %d", len(expected), len(entries))
+	}
+	for i, e := range expected {
+		var note string
+		if err := entries[i].Decode(&note); err != nil {
+			t.Error(err)
+		}
+		if note != e {
+			t.Errorf("Expected entry %d to be %s, was %s", i, e, note)
+		}
+		if entries[i].Time.IsZero() {
+			t.Errorf("Expected entry %d to have a timestamp", i)
+		}
+	}
+}
+
+func TestLogEmpty(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	entries, err := tags.Tag("1234", "5678", "notes").Log(3).Entries()
+	if err != nil {
+		t.Error(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected log to be empty, was %v", entries)
+	}
+}
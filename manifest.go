@@ -0,0 +1,157 @@
+package tango
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+)
+
+// A Setting declares a key used by the application, along with the kind of
+// the values it holds, its default value, its constraints and a description
+// of its purpose.
+type Setting struct {
+	// Key is the key of the tag holding the setting.
+	Key string `json:"key"`
+
+	// Kind is the kind of the values of the setting. KindInvalid, the zero
+	// value, accepts values of any kind.
+	Kind Kind `json:"kind"`
+
+	// Default is the value of the setting for entities where it is not set.
+	Default any `json:"default,omitempty"`
+
+	// Enum is the list of allowed values, if the setting is enumerated.
+	Enum []any `json:"enum,omitempty"`
+
+	// Range is the range of allowed values, if the setting is numeric.
+	Range *Range `json:"range,omitempty"`
+
+	// Description explains the purpose of the setting to humans.
+	Description string `json:"description,omitempty"`
+
+	// Sensitive marks the setting as holding secrets, such as tokens.
+	Sensitive bool `json:"sensitive,omitempty"`
+}
+
+// A Range is an interval of numbers, both ends included. Open ended ranges
+// use math.Inf for the missing end, which is left out when encoded as JSON.
+type Range struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// jsonRange is the JSON representation of a Range, where the infinite ends
+// are missing.
+type jsonRange struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+// MarshalJSON encodes the range, leaving out the ends that are infinite,
+// since JSON cannot represent them.
+func (r Range) MarshalJSON() ([]byte, error) {
+	var encoded jsonRange
+	if !math.IsInf(r.Min, 0) {
+		encoded.Min = &r.Min
+	}
+	if !math.IsInf(r.Max, 0) {
+		encoded.Max = &r.Max
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes a range encoded using MarshalJSON, where the
+// missing ends are infinite.
+func (r *Range) UnmarshalJSON(data []byte) error {
+	var decoded jsonRange
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	r.Min, r.Max = math.Inf(-1), math.Inf(1)
+	if decoded.Min != nil {
+		r.Min = *decoded.Min
+	}
+	if decoded.Max != nil {
+		r.Max = *decoded.Max
+	}
+	return nil
+}
+
+// A Manifest declares every setting of an application. The engine uses the
+// manifest given to WithManifest to validate writes, to serve default values
+// and to list the settings of an entity.
+type Manifest struct {
+	settings []Setting
+	index    map[string]int
+}
+
+// NewManifest returns a manifest declaring the given settings. If a key is
+// declared more than once, the last declaration wins.
+func NewManifest(settings ...Setting) *Manifest {
+	m := &Manifest{index: make(map[string]int)}
+	for _, setting := range settings {
+		if i, ok := m.index[setting.Key]; ok {
+			m.settings[i] = setting
+			continue
+		}
+		m.index[setting.Key] = len(m.settings)
+		m.settings = append(m.settings, setting)
+	}
+	return m
+}
+
+// Settings returns every setting of the manifest, in declaration order.
+func (m *Manifest) Settings() []Setting {
+	return append([]Setting(nil), m.settings...)
+}
+
+// Lookup returns the setting declared for the given key, if any.
+func (m *Manifest) Lookup(key string) (Setting, bool) {
+	i, ok := m.index[key]
+	if !ok {
+		return Setting{}, false
+	}
+	return m.settings[i], true
+}
+
+// A SettingValue is the value of a setting for an entity.
+type SettingValue struct {
+	Setting
+
+	// Value is the JSON representation of the value of the setting, which
+	// is the default value if it is not set. It is nil if the setting is
+	// not set and has no default value. The value is masked if the
+	// setting is sensitive.
+	Value json.RawMessage `json:"value,omitempty"`
+
+	// IsSet reports whether the setting is actually set for the entity.
+	IsSet bool `json:"is_set"`
+}
+
+// Settings lists the value of every setting declared in the manifest of the
+// engine for the given entity, in declaration order, so that user
+// interfaces can render them. It returns nil if the engine has no manifest.
+func (tags *Tags) Settings(universe, entity string) ([]SettingValue, error) {
+	if tags.manifest == nil {
+		return nil, nil
+	}
+	ctx, cancel := tags.context(context.Background())
+	defer cancel()
+	values, err := tags.TagBag(universe, entity).values(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]SettingValue, len(tags.manifest.settings))
+	for i, setting := range tags.manifest.settings {
+		result[i].Setting = setting
+		if raw, ok := values[setting.Key]; ok {
+			result[i].Value, result[i].IsSet = raw, true
+		} else if raw, ok := tags.defaults[setting.Key]; ok {
+			result[i].Value = json.RawMessage(raw)
+		}
+		if setting.Sensitive && result[i].Value != nil {
+			result[i].Value = json.RawMessage(`"` + redacted + `"`)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,99 @@
+package tango
+
+import (
+	"errors"
+	"testing"
+)
+
+var testManifest = NewManifest(
+	Setting{Key: "prefix", Kind: KindString, Default: "!", Description: "Prefix of the commands"},
+	Setting{Key: "log_level", Kind: KindString, Enum: []any{"off", "errors", "all"}},
+	Setting{Key: "xp_multiplier", Kind: KindNumber, Range: &Range{Min: 0, Max: 10}, Default: 1},
+	Setting{Key: "token", Kind: KindString, Sensitive: true},
+)
+
+func TestManifestValidation(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithManifest(testManifest))
+
+	e := tags.Entity("1234", "5678")
+	if err := e.Set("prefix", 33); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("Expected ErrTypeMismatch, got %v", err)
+	}
+	if err := e.Set("log_level", "verbose"); !errors.Is(err, ErrInvalidEnum) {
+		t.Errorf("Expected ErrInvalidEnum, got %v", err)
+	}
+	if err := e.Set("xp_multiplier", 1e9); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("Expected ErrOutOfRange, got %v", err)
+	}
+	if err := e.Set("undeclared", 33); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestManifestDefaults(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithManifest(testManifest))
+
+	e := tags.Entity("1234", "5678")
+	if prefix, ok, err := e.String("prefix"); err != nil || !ok || prefix != "!" {
+		t.Errorf("Expected default prefix '!', was `%s` (%v, %v)", prefix, ok, err)
+	}
+	if err := e.Set("prefix", "?"); err != nil {
+		t.Error(err)
+	}
+	if prefix, ok, err := e.String("prefix"); err != nil || !ok || prefix != "?" {
+		t.Errorf("Expected prefix '?', was `%s` (%v, %v)", prefix, ok, err)
+	}
+	if _, ok, err := e.String("log_level"); err != nil || ok {
+		t.Errorf("Expected log_level not to be set (%v, %v)", ok, err)
+	}
+}
+
+func TestManifestSettings(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithManifest(testManifest))
+
+	e := tags.Entity("1234", "5678")
+	if err := e.Set("token", "s3cr3t"); err != nil {
+		t.Error(err)
+	}
+	if err := e.Set("xp_multiplier", 2); err != nil {
+		t.Error(err)
+	}
+	settings, err := tags.Settings("1234", "5678")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(settings) != 4 {
+		t.Fatalf("Expected 4 settings, got %d", len(settings))
+	}
+	expected := []struct {
+		key   string
+		value string
+		set   bool
+	}{
+		{"prefix", `"!"`, false},
+		{"log_level", ``, false},
+		{"xp_multiplier", `2`, true},
+		{"token", `"[redacted]"`, true},
+	}
+	for i, want := range expected {
+		got := settings[i]
+		if got.Key != want.key || string(got.Value) != want.value || got.IsSet != want.set {
+			t.Errorf("Expected %s to be %s (%v), was %s (%v)", want.key, want.value, want.set, got.Value, got.IsSet)
+		}
+	}
+}
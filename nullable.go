@@ -0,0 +1,52 @@
+package tango
+
+import "context"
+
+// TagState describes whether a tag holds a value in the persistence.
+type TagState int
+
+const (
+	// Missing means that the tag is not set for the entity.
+	Missing TagState = iota
+
+	// Null means that the tag is set for the entity, but its value is null.
+	Null
+
+	// Present means that the tag is set for the entity to a non-null value.
+	Present
+)
+
+// String returns a human readable representation of the state.
+func (state TagState) String() string {
+	switch state {
+	case Missing:
+		return "missing"
+	case Null:
+		return "null"
+	case Present:
+		return "present"
+	}
+	return "unknown"
+}
+
+// GetNullable works like Get, but it reports whether the tag is missing,
+// set to null or set to an actual value. The value is only decoded into
+// the out variable when the state is Present.
+func (tag *Tag) GetNullable(out any, opts ...OpOption) (TagState, error) {
+	tag.warnDeprecated("get")
+	ctx := withOpOptions(context.Background(), opts)
+	raw, exists, err := tag.tags.store.GetTag(ctx, tag.universe, tag.entity, tag.name)
+	if err != nil {
+		return Missing, err
+	}
+	if !exists {
+		return Missing, nil
+	}
+	if string(raw) == "null" {
+		return Null, nil
+	}
+	if err := tag.decode(raw, out); err != nil {
+		return Missing, tag.tags.failed("get", tag, err)
+	}
+	return Present, nil
+}
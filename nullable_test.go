@@ -0,0 +1,61 @@
+package tango
+
+import "testing"
+
+func TestTagsGetNullableStates(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', '5678', 'nully', 'null'),
+		('1234', '5678', 'string', '"hello"')`); err != nil {
+		t.Error(err)
+	}
+
+	var result string
+	cases := map[string]TagState{
+		"missing": Missing,
+		"nully":   Null,
+		"string":  Present,
+	}
+	for key, expected := range cases {
+		state, err := tags.Tag("1234", "5678", key).GetNullable(&result)
+		if err != nil {
+			t.Error(err)
+		}
+		if state != expected {
+			t.Errorf("Expected key %s to be %s, was %s", key, expected, state)
+		}
+	}
+	if result != "hello" {
+		t.Errorf("Expected key to resolve to 'hello', was `%s`", result)
+	}
+}
+
+func TestTagsNullAsDelete(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithNullAsDelete())
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', '5678', 'thing', '"foobar"')`); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "5678", "thing").Set(nil); err != nil {
+		t.Error(err)
+	}
+
+	var result *string
+	state, err := tags.Tag("1234", "5678", "thing").GetNullable(&result)
+	if err != nil {
+		t.Error(err)
+	}
+	if state != Missing {
+		t.Errorf("Expected key to be missing, was %s", state)
+	}
+}
@@ -0,0 +1,98 @@
+package tango
+
+import (
+	"context"
+	"time"
+)
+
+// An OpOption tunes a single operation over a tag, overriding the settings
+// of the engine for that operation only. Operation options travel in the
+// context given to the TagStore, so they reach the engine even if the
+// store is decorated.
+type OpOption func(*opConfig)
+
+type opConfig struct {
+	timeout   time.Duration
+	skipCache bool
+	ttl       time.Duration
+	actor     string
+}
+
+type opConfigKey struct{}
+
+// WithOpTimeout bounds the duration of the operation, overriding the
+// timeout configured for the engine using WithTimeout.
+func WithOpTimeout(timeout time.Duration) OpOption {
+	return func(cfg *opConfig) {
+		cfg.timeout = timeout
+	}
+}
+
+// SkipCache makes a read ignore the values held in the cache, always
+// reading the value from the database. The value read is still put in the
+// cache for the next reads.
+func SkipCache() OpOption {
+	return func(cfg *opConfig) {
+		cfg.skipCache = true
+	}
+}
+
+// Strong makes a read consistent with the database, for critical reads
+// such as permission flags that must never be served stale. It is the same
+// as SkipCache.
+func Strong() OpOption {
+	return SkipCache()
+}
+
+// Eventual lets a read be served from the cache, even if the values may be
+// stale. This is the default, but it can be used to relax a read whose
+// context was given Strong or SkipCache using ContextWithOptions.
+func Eventual() OpOption {
+	return func(cfg *opConfig) {
+		cfg.skipCache = false
+	}
+}
+
+// WithTTL sets how long the value read or written by the operation is kept
+// in the cache, overriding the ttl given to WithCache. By default, written
+// values are evicted from the cache, but writes using this option will put
+// the written value in the cache instead. It has no effect if the engine
+// has no cache.
+func WithTTL(ttl time.Duration) OpOption {
+	return func(cfg *opConfig) {
+		cfg.ttl = ttl
+	}
+}
+
+// AsActor records the given actor, such as the ID of the user running a
+// command, as the author of a write. The actor is only stored if the
+// engine was configured using WithTracking.
+func AsActor(actor string) OpOption {
+	return func(cfg *opConfig) {
+		cfg.actor = actor
+	}
+}
+
+// ContextWithOptions returns a context that carries the given operation
+// options, for code that calls a TagStore directly.
+func ContextWithOptions(ctx context.Context, opts ...OpOption) context.Context {
+	return withOpOptions(ctx, opts)
+}
+
+// withOpOptions returns a context that carries the given options.
+func withOpOptions(ctx context.Context, opts []OpOption) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+	cfg := opConfigFrom(ctx)
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return context.WithValue(ctx, opConfigKey{}, cfg)
+}
+
+// opConfigFrom returns the options carried by the context.
+func opConfigFrom(ctx context.Context) opConfig {
+	cfg, _ := ctx.Value(opConfigKey{}).(opConfig)
+	return cfg
+}
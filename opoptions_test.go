@@ -0,0 +1,132 @@
+package tango
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOpOptionsSkipCache(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithCache(time.Minute, 10))
+
+	tag := tags.Tag("1234", "5678", "string")
+	if err := tag.Set("hello"); err != nil {
+		t.Error(err)
+	}
+	var result string
+	if _, err := tag.Get(&result); err != nil {
+		t.Error(err)
+	}
+	if _, err := db.Exec(`UPDATE tags SET value = '"bye"'`); err != nil {
+		t.Error(err)
+	}
+	if _, err := tag.Get(&result, SkipCache()); err != nil {
+		t.Error(err)
+	}
+	if result != "bye" {
+		t.Errorf("Expected fresh value 'bye', was `%s`", result)
+	}
+
+	// The fresh value is cached for the next reads.
+	if _, err := tag.Get(&result); err != nil {
+		t.Error(err)
+	}
+	if result != "bye" {
+		t.Errorf("Expected cached value 'bye', was `%s`", result)
+	}
+}
+
+func TestOpOptionsConsistency(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithCache(time.Minute, 10))
+
+	tag := tags.Tag("1234", "5678", "string")
+	if err := tag.Set("hello"); err != nil {
+		t.Error(err)
+	}
+	var result string
+	if _, err := tag.Get(&result); err != nil {
+		t.Error(err)
+	}
+	if _, err := db.Exec(`UPDATE tags SET value = '"bye"'`); err != nil {
+		t.Error(err)
+	}
+
+	// The last option given wins, so the read is served from the cache.
+	if _, err := tag.Get(&result, Strong(), Eventual()); err != nil {
+		t.Error(err)
+	}
+	if result != "hello" {
+		t.Errorf("Expected cached value 'hello', was `%s`", result)
+	}
+	if _, err := tag.Get(&result, Strong()); err != nil {
+		t.Error(err)
+	}
+	if result != "bye" {
+		t.Errorf("Expected fresh value 'bye', was `%s`", result)
+	}
+}
+
+func TestOpOptionsTTL(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithCache(time.Minute, 10))
+
+	// A write with a ttl puts the written value in the cache.
+	tag := tags.Tag("1234", "5678", "string")
+	if err := tag.Set("hello", WithTTL(time.Hour)); err != nil {
+		t.Error(err)
+	}
+	if _, err := db.Exec(`UPDATE tags SET value = '"bye"'`); err != nil {
+		t.Error(err)
+	}
+	var result string
+	if _, err := tag.Get(&result); err != nil {
+		t.Error(err)
+	}
+	if result != "hello" {
+		t.Errorf("Expected cached value 'hello', was `%s`", result)
+	}
+
+	// A short ttl makes the value expire early.
+	if err := tag.Set("world", WithTTL(time.Nanosecond)); err != nil {
+		t.Error(err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, err := tag.Get(&result); err != nil {
+		t.Error(err)
+	}
+	if result != "world" {
+		t.Errorf("Expected value 'world', was `%s`", result)
+	}
+}
+
+func TestOpOptionsTimeout(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	tag := tags.Tag("1234", "5678", "string")
+	err = tag.Set("hello", WithOpTimeout(time.Nanosecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline to be exceeded, got %v", err)
+	}
+	if err := tag.Set("hello", WithOpTimeout(time.Minute)); err != nil {
+		t.Error(err)
+	}
+}
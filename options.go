@@ -0,0 +1,478 @@
+package tango
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// An Option configures the behaviour of a tags engine. Options are given
+// when the engine is created using NewTagsEngine. Every setting of the
+// engine is configured through an option, so that the engine is never
+// modified once it is in use.
+type Option func(*Tags)
+
+// WithTable sets the name of the table where tags are persisted. The table
+// is named tags by default. Additional tables required by some features
+// are named after this table, so with a table named settings, summaries
+// will be stored in the settings_summaries table.
+func WithTable(name string) Option {
+	return func(tags *Tags) {
+		tags.table = name
+	}
+}
+
+// WithTimeout sets the maximum amount of time that every operation over a
+// tag, a tagbag or an entity may take before it is cancelled, along with
+// the quick operations over the engine such as listing a page of entities,
+// Ping, TryLock and checkpoints. Operations over a whole universe or store,
+// such as bulk upserts, exports, imports, snapshots, replays, conformance
+// checks and usage reports, are only bounded by the context given to them.
+// By default, operations are not bounded.
+func WithTimeout(timeout time.Duration) Option {
+	return func(tags *Tags) {
+		tags.timeout = timeout
+	}
+}
+
+// WithCodec sets the codec used to encode and decode the values of the
+// tags. By default, JSONCodec is used.
+func WithCodec(codec Codec) Option {
+	return func(tags *Tags) {
+		tags.codec = codec
+	}
+}
+
+// WithLogger sets the logger used to report errors. By default, the
+// engine does not log anything.
+func WithLogger(logger *log.Logger) Option {
+	return func(tags *Tags) {
+		tags.logger = logger
+	}
+}
+
+// WithExpvar publishes the statistics of the engine through the expvar
+// package using the given name, so that they are served by the
+// /debug/vars endpoint. If another engine was published using the same
+// name, it is replaced by this engine.
+func WithExpvar(name string) Option {
+	return func(tags *Tags) {
+		publishStats(name, tags)
+	}
+}
+
+// WithKeyStats counts the operations made over every key of every
+// universe, so that the busiest keys are listed by Usage. Only the
+// counters of the most frequent keys are kept, so that the memory used is
+// bounded even if keys are derived from user input. By default, only the
+// conflicts are counted per key.
+func WithKeyStats() Option {
+	return func(tags *Tags) {
+		tags.stats.keys = newKeyCounter()
+	}
+}
+
+// WithSlowOpThreshold reports every operation over a tag that takes at
+// least the given duration to the given function, which is useful to find
+// which tags are causing slow reads or writes. The function is called
+// synchronously once the operation ends, so it should return quickly.
+func WithSlowOpThreshold(threshold time.Duration, report func(OpInfo)) Option {
+	return func(tags *Tags) {
+		tags.slowOp = &slowOp{threshold: threshold, report: report}
+	}
+}
+
+// WithRetryClassifier sets a function that tells whether an error returned
+// by the database driver is transient, so that the Error returned by the
+// engine reports it as retryable. Errors such as timeouts, or SQLite
+// reporting that the database is busy or locked, are classified as
+// retryable without a classifier, but errors specific to other drivers can
+// only be classified by the application.
+func WithRetryClassifier(classifier func(error) bool) Option {
+	return func(tags *Tags) {
+		tags.classifier = classifier
+	}
+}
+
+// WithCache keeps the values read from the database in memory for the
+// given amount of time, holding at most size values, or unlimited values
+// if size is zero. Values written through the engine are evicted from the
+// cache, but values written by other processes or engines sharing the
+// same database will only be seen after the cached values expire.
+func WithCache(ttl time.Duration, size int) Option {
+	return func(tags *Tags) {
+		tags.cache = newCache(ttl, size)
+		tags.cache.onEvict = tags.stats.cacheEviction
+	}
+}
+
+// WithPortableIDs rejects writes to tags whose universe, entity or key are
+// not in the portable form described by EscapeID with ErrInvalidID, so
+// that the data can be moved into other kinds of stores.
+func WithPortableIDs() Option {
+	return func(tags *Tags) {
+		tags.portableIDs = true
+	}
+}
+
+// WithTombstones records when tags are deleted, so that ExportChangedSince
+// can export the deletions. Tags removed by DeleteUniverse or by imports
+// leave no tombstone. The tombstones table described in the package
+// documentation must exist, and PruneTombstones should be called from time
+// to time to remove the tombstones that are no longer needed.
+func WithTombstones() Option {
+	return func(tags *Tags) {
+		tags.tombstones = true
+	}
+}
+
+// WithReadTracking records when tags were last read, so that UnusedKeys
+// can report which keys are not read anymore. Since recording a read is a
+// write, only the given ratio of reads is recorded, between 0 and 1. The
+// tags table needs the additional column described in the package
+// documentation.
+func WithReadTracking(sampleRate float64) Option {
+	return func(tags *Tags) {
+		tags.readSampleRate = sampleRate
+	}
+}
+
+// WithStrictIDs makes operations over tags whose universe, entity or key
+// are empty or longer than maxLength bytes fail with ErrInvalidID, instead
+// of silently reading or writing rows that nobody can address. A zero
+// maxLength does not limit the length. The schema described in the package
+// documentation limits identifiers to 64 characters.
+func WithStrictIDs(maxLength int) Option {
+	return func(tags *Tags) {
+		tags.strictIDs = true
+		tags.maxIDLength = maxLength
+	}
+}
+
+// WithUniverses restricts the engine to the given universes. Reading,
+// writing or deleting tags of any other universe fails with
+// ErrUnknownUniverse, which prevents bugs such as writing data under an
+// empty universe from going unnoticed.
+func WithUniverses(universes ...string) Option {
+	allowed := make(map[string]bool, len(universes))
+	for _, universe := range universes {
+		allowed[universe] = true
+	}
+	return WithUniverseFilter(func(universe string) bool {
+		return allowed[universe]
+	})
+}
+
+// WithUniverseFilter restricts the engine to the universes for which the
+// given function returns true, in the same way as WithUniverses.
+func WithUniverseFilter(allow func(universe string) bool) Option {
+	return func(tags *Tags) {
+		tags.universeFilter = allow
+	}
+}
+
+// WithBloomFilter keeps an in-memory bloom filter of the keys set in every
+// universe, so that reads of keys that are definitely not set do not have
+// to query the database. The filter of a universe is loaded on the first
+// read made to it, and it is sized for the expected number of keys per
+// universe and the given false positive rate, 0.01 if not between 0 and 1.
+// Keys written by other processes or engines sharing the same database
+// after the filter is loaded will not be seen, so it should only be used
+// if the engine is the only writer of the database.
+func WithBloomFilter(expected int, fpRate float64) Option {
+	return func(tags *Tags) {
+		tags.bloom = newBloomFilters(expected, fpRate)
+	}
+}
+
+// WithStore decorates the store used by the tags, tagbags and entities
+// created by the engine. The decorator receives the store that would be used
+// otherwise and returns the store that should be used instead, usually a
+// wrapper that adds some behaviour such as metrics or authorization before
+// calling the given store. When this option is given several times, the
+// last decorator is the outermost one.
+//
+// Decorators intercept the operations that map into the methods of a
+// TagStore: getting, setting and deleting tags in every form, such as
+// Tag.Get, Tag.GetRaw, Tag.Kind, Tag.GetNullable or the helpers of Entity,
+// and listing the tags of a tagbag using TagBag.Tags. The operations that
+// need their own statements to be atomic or efficient cannot go through
+// the store, so they fail with ErrDecoratedStore when a decorator is
+// installed: Tag.Swap, Tag.GetOrSet, Tag.SetOnce, Tag.SetIfAbsent,
+// Tag.DeleteExisting, Tag.DeleteIf, Tag.SetIfUnmodifiedSince, Tag.Metadata,
+// Tag.Exists, collections and logs, TagBag.DeletePrefix, TagBag.HasMany,
+// TagBag.TagsPage, TagBag.WriteJSON, TagBag.Format and Tags.Update.
+func WithStore(decorator func(TagStore) TagStore) Option {
+	return func(tags *Tags) {
+		tags.decorators = append(tags.decorators, decorator)
+	}
+}
+
+// WithTracking makes the engine record when every tag was last written and
+// by whom, as given using AsActor, so that it can be read using
+// Tag.Metadata. It requires the tracking columns in the tags table.
+func WithTracking() Option {
+	return func(tags *Tags) {
+		tags.tracking = true
+	}
+}
+
+// WithNullAsDelete makes the engine treat a Set(nil) as a Delete, so that
+// a tag set to a JSON null is removed from the persistence instead of
+// being stored. By default, null values are stored like any other value.
+func WithNullAsDelete() Option {
+	return func(tags *Tags) {
+		tags.nullAsDelete = true
+	}
+}
+
+// WithAlias registers an alias for a key that has been renamed. Tags
+// requested using the old name will transparently use the new name. When
+// the new key is missing, data still stored under the old name is read
+// instead, so that keys can be renamed without migrating the data first.
+// Writes always target the new key and clean up data under the old name.
+func WithAlias(oldKey, newKey string) Option {
+	return func(tags *Tags) {
+		if tags.aliases == nil {
+			tags.aliases = make(map[string]string)
+			tags.legacy = make(map[string][]string)
+		}
+		tags.aliases[oldKey] = newKey
+		tags.legacy[newKey] = append(tags.legacy[newKey], oldKey)
+	}
+}
+
+// WithAliasRewrite makes the engine migrate data stored under an old key
+// name into the new key name the first time it is read, so that data is
+// progressively moved to the new keys. Data is not migrated by read-only
+// engines, nor if it violates the constraints of the new key.
+func WithAliasRewrite() Option {
+	return func(tags *Tags) {
+		tags.rewriteAliases = true
+	}
+}
+
+// WithDeprecatedKeys marks the given keys as deprecated. Reading or writing
+// a deprecated key will trigger the hook configured using
+// WithDeprecationHook. Keys can be deprecated using either their current
+// name or the name of an alias.
+func WithDeprecatedKeys(keys ...string) Option {
+	return func(tags *Tags) {
+		if tags.deprecated == nil {
+			tags.deprecated = make(map[string]bool)
+		}
+		for _, key := range keys {
+			tags.deprecated[key] = true
+		}
+	}
+}
+
+// WithDeprecationHook sets the function that will be called every time a
+// deprecated key is used. It can be used to log or count the remaining
+// usages of the deprecated keys before dropping them.
+func WithDeprecationHook(hook func(Deprecation)) Option {
+	return func(tags *Tags) {
+		tags.deprecationHook = hook
+	}
+}
+
+// WithLoader registers a loader for the given key. When a tag for the key
+// is read but it is not set, the loader will be called to compute the
+// value, which will be validated and persisted so that next reads find it.
+// Read-only engines return the computed value without persisting it.
+func WithLoader(key string, loader Loader) Option {
+	return func(tags *Tags) {
+		if tags.loaders == nil {
+			tags.loaders = make(map[string]Loader)
+		}
+		tags.loaders[key] = loader
+	}
+}
+
+// WithDerived registers a derived key, whose value is computed using the
+// given derivation every time a tag for the key is read, and is never
+// stored. Writing a tag for a derived key fails with ErrDerivedKey.
+func WithDerived(key string, derivation Derivation) Option {
+	return func(tags *Tags) {
+		if tags.derived == nil {
+			tags.derived = make(map[string]Derivation)
+		}
+		tags.derived[key] = derivation
+	}
+}
+
+// WithDependencies declares that the value of the given key depends on the
+// values of other keys of the same entity. Writing any of these keys evicts
+// the key from the cache, along with any key that depends on it in turn.
+// Derived keys with declared dependencies are cached like any other key,
+// instead of being computed on every read.
+func WithDependencies(key string, dependsOn ...string) Option {
+	return func(tags *Tags) {
+		if tags.dependencies == nil {
+			tags.dependencies = make(map[string][]string)
+			tags.dependentKeys = make(map[string][]string)
+		}
+		tags.dependencies[key] = append(tags.dependencies[key], dependsOn...)
+		for _, dependency := range dependsOn {
+			tags.dependentKeys[dependency] = append(tags.dependentKeys[dependency], key)
+		}
+	}
+}
+
+// WithValidator registers a validator that will be called before setting
+// any tag, regardless of the universe. Validators are called in the order
+// they were registered.
+func WithValidator(validator Validator) Option {
+	return func(tags *Tags) {
+		tags.validators = append(tags.validators, validator)
+	}
+}
+
+// WithUniverseValidator registers a validator that will be called before
+// setting any tag of the given universe. Universe validators are called
+// after the validators registered using WithValidator.
+func WithUniverseValidator(universe string, validator Validator) Option {
+	return func(tags *Tags) {
+		if tags.universeValidators == nil {
+			tags.universeValidators = make(map[string][]Validator)
+		}
+		tags.universeValidators[universe] = append(tags.universeValidators[universe], validator)
+	}
+}
+
+// WithImmutableKeys marks the given keys as write-once. Setting a tag for
+// any of these keys will fail with ErrImmutable if the tag is already set.
+// Immutable tags may still be deleted.
+func WithImmutableKeys(keys ...string) Option {
+	return func(tags *Tags) {
+		if tags.immutable == nil {
+			tags.immutable = make(map[string]bool)
+		}
+		for _, key := range keys {
+			tags.immutable[key] = true
+		}
+	}
+}
+
+// WithEnum constrains the values of the given key to the given list of
+// allowed values. Setting the key to any other value fails with
+// ErrInvalidEnum. Values are compared using their JSON representation.
+func WithEnum(key string, allowed ...any) Option {
+	return func(tags *Tags) {
+		if tags.constraints == nil {
+			tags.constraints = make(map[string][]constraint)
+		}
+		tags.constraints[key] = append(tags.constraints[key], enumConstraint(allowed))
+	}
+}
+
+// WithRange constrains the values of the given key to numbers between min
+// and max, both included. Setting the key to any other value fails with
+// ErrOutOfRange. Use math.Inf to leave one of the ends unbounded.
+func WithRange(key string, min, max float64) Option {
+	return func(tags *Tags) {
+		if tags.constraints == nil {
+			tags.constraints = make(map[string][]constraint)
+		}
+		tags.constraints[key] = append(tags.constraints[key], rangeConstraint(min, max))
+	}
+}
+
+// WithManifest declares the settings of the application using the given
+// manifest. Writes to the declared keys are checked against the kind and
+// the constraints of their settings, reads of unset keys return the default
+// value of their settings, and sensitive settings are masked.
+func WithManifest(manifest *Manifest) Option {
+	return func(tags *Tags) {
+		tags.manifest = manifest
+		for _, setting := range manifest.settings {
+			key := setting.Key
+			if setting.Kind != KindInvalid {
+				WithKind(key, setting.Kind)(tags)
+			}
+			if len(setting.Enum) > 0 {
+				WithEnum(key, setting.Enum...)(tags)
+			}
+			if setting.Range != nil {
+				WithRange(key, setting.Range.Min, setting.Range.Max)(tags)
+			}
+			if setting.Sensitive {
+				WithSensitiveKeys(key)(tags)
+			}
+			if setting.Default != nil {
+				if raw, err := json.Marshal(setting.Default); err == nil {
+					if tags.defaults == nil {
+						tags.defaults = make(map[string]string)
+					}
+					tags.defaults[key] = string(raw)
+				}
+			}
+		}
+	}
+}
+
+// WithStartupValidation makes NewTagsEngine start checking the values
+// stored for the settings declared in the manifest in the background, as
+// ValidateManifest does, and call report from another goroutine with the
+// violations found, if any, so that a deploy that breaks the expectations
+// of the stored data is noticed early without delaying the start of the
+// application. If sample is positive, only that many tags picked at random
+// are checked, which is advised for large tables. Errors running the
+// validation are logged, since they cannot be returned by NewTagsEngine. It
+// has no effect unless WithManifest is used.
+func WithStartupValidation(sample int, report func([]Violation)) Option {
+	return func(tags *Tags) {
+		tags.startupSample = sample
+		tags.startupReport = report
+	}
+}
+
+// WithKind constrains the values of the given key to the given kind.
+// Setting the key to a value of a different kind fails with
+// ErrTypeMismatch.
+func WithKind(key string, kind Kind) Option {
+	return func(tags *Tags) {
+		if tags.constraints == nil {
+			tags.constraints = make(map[string][]constraint)
+		}
+		tags.constraints[key] = append(tags.constraints[key], kindConstraint(kind))
+	}
+}
+
+// WithReferences enables the resolution of references on read. Tags holding
+// a reference, as created by Ref, are read as the value of the referenced
+// tag, following at most maxDepth references in a row. Longer chains fail
+// with ErrReferenceDepth, and chains that loop fail with ErrReferenceCycle.
+// Writes are not affected, so setting a tag replaces the reference itself.
+func WithReferences(maxDepth int) Option {
+	return func(tags *Tags) {
+		tags.maxRefDepth = maxDepth
+	}
+}
+
+// WithSensitiveKeys marks the given keys as sensitive, such as keys that
+// hold tokens or passwords. The values of sensitive keys are masked when a
+// tagbag is rendered using TagBag.Format.
+func WithSensitiveKeys(keys ...string) Option {
+	return func(tags *Tags) {
+		if tags.sensitive == nil {
+			tags.sensitive = make(map[string]bool)
+		}
+		for _, key := range keys {
+			tags.sensitive[key] = true
+		}
+	}
+}
+
+// WithSummary registers a summary with the given name, which aggregates the
+// values of the given key across every entity of a universe. Summaries are
+// maintained incrementally on every write, and they can be read using
+// Tags.Summary. This option requires the summaries table.
+func WithSummary(name, key string, aggregation Aggregation) Option {
+	return func(tags *Tags) {
+		if tags.summaries == nil {
+			tags.summaries = make(map[string][]*summary)
+		}
+		tags.summaries[key] = append(tags.summaries[key], &summary{name: name, aggregation: aggregation})
+	}
+}
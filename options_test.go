@@ -0,0 +1,168 @@
+package tango
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOptionsTable(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE settings(
+		id INTEGER PRIMARY KEY,
+		universe VARCHAR(64) NOT NULL,
+		entity VARCHAR(64) NOT NULL,
+		key VARCHAR(64) NOT NULL,
+		value TEXT
+	);
+	CREATE UNIQUE INDEX settings_id ON settings(universe, entity, key);`); err != nil {
+		t.Fatal(err)
+	}
+	tags := NewTagsEngine(db, WithTable("settings"))
+
+	if err := tags.Tag("1234", "5678", "string").Set("hello"); err != nil {
+		t.Error(err)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM settings`).Scan(&count); err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected tag to be persisted into the settings table")
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM tags`).Scan(&count); err != nil {
+		t.Error(err)
+	}
+	if count != 0 {
+		t.Errorf("Expected tag not to be persisted into the tags table")
+	}
+}
+
+type numberCodec struct {
+	JSONCodec
+}
+
+func (numberCodec) Unmarshal(data []byte, out any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(out)
+}
+
+func TestOptionsCodec(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithCodec(numberCodec{}))
+
+	if err := tags.Tag("1234", "5678", "big").Set(uint64(1 << 60)); err != nil {
+		t.Error(err)
+	}
+	var result any
+	if _, err := tags.Tag("1234", "5678", "big").Get(&result); err != nil {
+		t.Error(err)
+	}
+	if n, ok := result.(json.Number); !ok || n.String() != "1152921504606846976" {
+		t.Errorf("Expected codec to decode a json.Number, got %#v", result)
+	}
+}
+
+func TestOptionsLogger(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	var buf bytes.Buffer
+	tags := NewTagsEngine(db, WithLogger(log.New(&buf, "", 0)))
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', '5678', 'string', '"hello"')`); err != nil {
+		t.Error(err)
+	}
+	var result int
+	if _, err := tags.Tag("1234", "5678", "string").Get(&result); err == nil {
+		t.Errorf("Expected decoding a string into an int to fail")
+	}
+	if !strings.Contains(buf.String(), "get 1234/5678/string") {
+		t.Errorf("Expected error to be logged, got %q", buf.String())
+	}
+}
+
+func TestOptionsTimeout(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithTimeout(time.Second))
+
+	if err := tags.Tag("1234", "5678", "string").Set("hello"); err != nil {
+		t.Error(err)
+	}
+	var result string
+	if _, err := tags.Tag("1234", "5678", "string").Get(&result); err != nil {
+		t.Error(err)
+	}
+	if result != "hello" {
+		t.Errorf("Expected key to resolve to 'hello', was `%s`", result)
+	}
+
+	// Operations over a whole universe are not bounded by the timeout.
+	short := NewTagsEngine(db, WithTimeout(time.Nanosecond))
+	var buf bytes.Buffer
+	if err := short.WriteUniverseJSON(context.Background(), "1234", &buf); err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(buf.String(), "hello") {
+		t.Errorf("Expected the universe to be exported, got %s", buf.String())
+	}
+}
+
+func TestOptionsCache(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithCache(time.Minute, 10))
+
+	tag := tags.Tag("1234", "5678", "string")
+	if err := tag.Set("hello"); err != nil {
+		t.Error(err)
+	}
+	var result string
+	if _, err := tag.Get(&result); err != nil {
+		t.Error(err)
+	}
+
+	// Changes made behind the engine are not seen while cached.
+	if _, err := db.Exec(`UPDATE tags SET value = '"bye"'`); err != nil {
+		t.Error(err)
+	}
+	if _, err := tag.Get(&result); err != nil {
+		t.Error(err)
+	}
+	if result != "hello" {
+		t.Errorf("Expected cached value 'hello', was `%s`", result)
+	}
+
+	// But changes made through the engine are.
+	if err := tag.Set("world"); err != nil {
+		t.Error(err)
+	}
+	if _, err := tag.Get(&result); err != nil {
+		t.Error(err)
+	}
+	if result != "world" {
+		t.Errorf("Expected value 'world', was `%s`", result)
+	}
+}
@@ -0,0 +1,73 @@
+package tango
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPlatformID is returned when a chat platform identifier is not
+// well formed.
+var ErrInvalidPlatformID = errors.New("tango: invalid platform identifier")
+
+// The identifiers of every platform are prefixed, so that bots running on
+// several platforms using the same engine never mix their data, even if
+// the identifiers happen to collide.
+const (
+	telegramPrefix = "telegram:"
+	matrixPrefix   = "matrix:"
+)
+
+// TelegramID returns the identifier used for a Telegram chat or user, to be
+// used either as a universe or as an entity. Telegram chat and user IDs are
+// numeric, and group chats have negative identifiers.
+func TelegramID(id int64) string {
+	return telegramPrefix + strconv.FormatInt(id, 10)
+}
+
+// MatrixID returns the identifier used for a Matrix room or user, to be used
+// either as a universe or as an entity. The identifier must include its
+// sigil and server name, such as "!room:example.org" or
+// "@user:example.org". Server names are case insensitive, so they are
+// lowercased to make the identifier consistent.
+func MatrixID(id string) (string, error) {
+	if len(id) < 2 || !strings.ContainsRune("!@#$+", rune(id[0])) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPlatformID, id)
+	}
+	local, server, ok := strings.Cut(id[1:], ":")
+	if !ok || local == "" || server == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPlatformID, id)
+	}
+	return matrixPrefix + id[:1] + local + ":" + strings.ToLower(server), nil
+}
+
+// TelegramChat returns a view of the engine bound to the given Telegram
+// chat.
+func (tags *Tags) TelegramChat(chat int64) *Universe {
+	return tags.ForUniverse(TelegramID(chat))
+}
+
+// MatrixRoom returns a view of the engine bound to the given Matrix room.
+func (tags *Tags) MatrixRoom(room string) (*Universe, error) {
+	id, err := MatrixID(room)
+	if err != nil {
+		return nil, err
+	}
+	return tags.ForUniverse(id), nil
+}
+
+// TelegramUser returns a handle to the given Telegram user in this
+// universe.
+func (u *Universe) TelegramUser(user int64) *Entity {
+	return u.Entity(TelegramID(user))
+}
+
+// MatrixUser returns a handle to the given Matrix user in this universe.
+func (u *Universe) MatrixUser(user string) (*Entity, error) {
+	id, err := MatrixID(user)
+	if err != nil {
+		return nil, err
+	}
+	return u.Entity(id), nil
+}
@@ -0,0 +1,58 @@
+package tango
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTelegramID(t *testing.T) {
+	if id := TelegramID(-1001234); id != "telegram:-1001234" {
+		t.Errorf("Unexpected identifier %s", id)
+	}
+}
+
+func TestMatrixID(t *testing.T) {
+	id, err := MatrixID("!AbCd:Example.ORG")
+	if err != nil {
+		t.Error(err)
+	}
+	if id != "matrix:!AbCd:example.org" {
+		t.Errorf("Unexpected identifier %s", id)
+	}
+	for _, invalid := range []string{"", "@", "user:example.org", "@user", "@:example.org", "@user:"} {
+		if _, err := MatrixID(invalid); !errors.Is(err, ErrInvalidPlatformID) {
+			t.Errorf("Expected %q to be invalid, got %v", invalid, err)
+		}
+	}
+}
+
+func TestPlatformHandles(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if err := tags.TelegramChat(-100).TelegramUser(42).Set("points", 10); err != nil {
+		t.Error(err)
+	}
+	room, err := tags.MatrixRoom("!room:example.org")
+	if err != nil {
+		t.Error(err)
+	}
+	user, err := room.MatrixUser("@user:example.org")
+	if err != nil {
+		t.Error(err)
+	}
+	if err := user.Set("points", 20); err != nil {
+		t.Error(err)
+	}
+
+	var points int
+	if _, err := tags.Tag("telegram:-100", "telegram:42", "points").Get(&points); err != nil || points != 10 {
+		t.Errorf("Expected telegram points to be 10, was %d (%v)", points, err)
+	}
+	if _, err := tags.Tag("matrix:!room:example.org", "matrix:@user:example.org", "points").Get(&points); err != nil || points != 20 {
+		t.Errorf("Expected matrix points to be 20, was %d (%v)", points, err)
+	}
+}
@@ -0,0 +1,71 @@
+package tango
+
+import "time"
+
+// progressEvery is the number of records processed between progress reports
+// by the operations that are not processed in chunks, such as snapshots.
+const progressEvery = 1000
+
+// A Progress receives reports about the progress of a long operation, such
+// as a bulk import or a snapshot, so that applications can display progress
+// bars or log how the operation is going.
+type Progress interface {
+	Report(info ProgressInfo)
+}
+
+// ProgressInfo describes how far a long operation is.
+type ProgressInfo struct {
+	// Op is the name of the operation, such as "bulk upsert".
+	Op string
+
+	// Processed is the number of records processed so far.
+	Processed int
+
+	// Total is the number of records the operation will process, or zero
+	// if it is not known in advance.
+	Total int
+
+	// Elapsed is the time since the operation started.
+	Elapsed time.Duration
+
+	// ETA is the estimated time left to finish the operation, or zero if
+	// it cannot be estimated.
+	ETA time.Duration
+}
+
+// ProgressFunc is an adapter to use ordinary functions as a Progress.
+type ProgressFunc func(info ProgressInfo)
+
+// Report calls the function.
+func (fn ProgressFunc) Report(info ProgressInfo) {
+	fn(info)
+}
+
+// A progressTracker reports the progress of an operation. A nil tracker or
+// a tracker without a Progress reports nothing.
+type progressTracker struct {
+	tags     *Tags
+	progress Progress
+	op       string
+	total    int
+	started  time.Time
+}
+
+// track starts tracking the progress of the given operation.
+func (tags *Tags) track(progress Progress, op string, total int) *progressTracker {
+	return &progressTracker{tags: tags, progress: progress, op: op, total: total, started: time.Now()}
+}
+
+// report reports that the given number of records has been processed.
+func (t *progressTracker) report(processed int) {
+	if t == nil || t.progress == nil {
+		return
+	}
+	info := ProgressInfo{Op: t.op, Processed: processed, Total: t.total, Elapsed: time.Since(t.started)}
+	if t.total > 0 && processed > 0 && processed <= t.total {
+		info.ETA = info.Elapsed * time.Duration(t.total-processed) / time.Duration(processed)
+	}
+	t.tags.notifyHook("progress", func() {
+		t.progress.Report(info)
+	})
+}
@@ -0,0 +1,52 @@
+package tango
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProgressETA(t *testing.T) {
+	var info ProgressInfo
+	tracker := (&Tags{}).track(ProgressFunc(func(i ProgressInfo) {
+		info = i
+	}), "test", 100)
+	tracker.started = time.Now().Add(-time.Minute)
+	tracker.report(25)
+	if info.Op != "test" || info.Processed != 25 || info.Total != 100 {
+		t.Errorf("Unexpected progress %+v", info)
+	}
+	if info.ETA < 3*time.Minute-time.Second || info.ETA > 3*time.Minute+time.Second {
+		t.Errorf("Expected ETA to be about 3 minutes, was %v", info.ETA)
+	}
+
+	// Nothing is reported without a progress.
+	var nilTracker *progressTracker
+	nilTracker.report(10)
+}
+
+func TestProgressSnapshot(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', '5678', 'a', '1'),
+		('1234', '5678', 'b', '2')`); err != nil {
+		t.Error(err)
+	}
+	var reports []ProgressInfo
+	var buf bytes.Buffer
+	err = tags.Snapshot(context.Background(), &buf, SnapshotOptions{Progress: ProgressFunc(func(info ProgressInfo) {
+		reports = append(reports, info)
+	})})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(reports) != 1 || reports[0].Op != "snapshot" || reports[0].Processed != 2 || reports[0].Total != 2 {
+		t.Errorf("Unexpected progress %+v", reports)
+	}
+}
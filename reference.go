@@ -0,0 +1,80 @@
+package tango
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// A Reference points to another tag. A tag whose value is an object with a
+// single "$ref" property holding a Reference is resolved on read into the
+// value of the referenced tag, if the engine was configured using
+// WithReferences. The universe and the entity default to the ones of the
+// referencing tag, so that references can point to other keys of the same
+// entity.
+type Reference struct {
+	Universe string `json:"universe,omitempty"`
+	Entity   string `json:"entity,omitempty"`
+	Key      string `json:"key"`
+}
+
+// Ref returns a value that, once set to a tag, makes it a reference to the
+// given tag.
+func Ref(universe, entity, key string) any {
+	return map[string]Reference{"$ref": {Universe: universe, Entity: entity, Key: key}}
+}
+
+// resolve follows the references starting at the given JSON representation
+// of the value of this tag, and returns the value of the last tag of the
+// chain, and whether it exists.
+func (tag *Tag) resolve(ctx context.Context, raw string, exists bool) (string, bool, error) {
+	visited := map[cacheKey]bool{{tag.universe, tag.entity, tag.key}: true}
+	current := tag
+	for depth := 0; exists; depth++ {
+		ref, ok := parseReference(raw)
+		if !ok {
+			break
+		}
+		if depth >= tag.tags.maxRefDepth {
+			return "", false, fmt.Errorf("%w: more than %d references", ErrReferenceDepth, tag.tags.maxRefDepth)
+		}
+		if ref.Universe == "" {
+			ref.Universe = current.universe
+		}
+		if ref.Entity == "" {
+			ref.Entity = current.entity
+		}
+		current = tag.tags.Tag(ref.Universe, ref.Entity, ref.Key)
+		key := cacheKey{current.universe, current.entity, current.key}
+		if visited[key] {
+			return "", false, fmt.Errorf("%w: %s/%s/%s", ErrReferenceCycle, key.universe, key.entity, key.key)
+		}
+		visited[key] = true
+		if err := tag.tags.checkAddress(current.universe, current.entity, current.key); err != nil {
+			return "", false, err
+		}
+		var err error
+		if raw, exists, err = current.fetch(ctx); err != nil {
+			return "", false, err
+		}
+	}
+	return raw, exists, nil
+}
+
+// parseReference returns the reference held by the given JSON
+// representation, if it is a reference.
+func parseReference(raw string) (Reference, bool) {
+	if !strings.Contains(raw, `"$ref"`) {
+		return Reference{}, false
+	}
+	var value map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &value); err != nil || len(value) != 1 {
+		return Reference{}, false
+	}
+	var ref Reference
+	if err := json.Unmarshal(value["$ref"], &ref); err != nil || ref.Key == "" {
+		return Reference{}, false
+	}
+	return ref, true
+}
@@ -0,0 +1,103 @@
+package tango
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestReferences(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithReferences(2))
+
+	if err := tags.Tag("templates", "welcome", "message").Set("Hello!"); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "5678", "welcome").Set(Ref("templates", "welcome", "message")); err != nil {
+		t.Error(err)
+	}
+	// References to the same entity only need the key.
+	if err := tags.Tag("1234", "5678", "greeting").Set(Ref("", "", "welcome")); err != nil {
+		t.Error(err)
+	}
+	var result string
+	for _, key := range []string{"welcome", "greeting"} {
+		exists, err := tags.Tag("1234", "5678", key).Get(&result)
+		if err != nil {
+			t.Error(err)
+		}
+		if !exists || result != "Hello!" {
+			t.Errorf("Expected %s to resolve to 'Hello!', was `%s` (%v)", key, result, exists)
+		}
+	}
+
+	// References to missing tags are missing too.
+	if err := tags.Tag("1234", "5678", "broken").Set(Ref("", "", "missing")); err != nil {
+		t.Error(err)
+	}
+	if exists, err := tags.Tag("1234", "5678", "broken").Get(&result); err != nil || exists {
+		t.Errorf("Expected broken reference not to exist (%v, %v)", exists, err)
+	}
+}
+
+func TestReferencesLimits(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithReferences(2))
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', '5678', 'a', '{"$ref": {"key": "b"}}'),
+		('1234', '5678', 'b', '{"$ref": {"key": "a"}}'),
+		('1234', '5678', 'c', '{"$ref": {"key": "d"}}'),
+		('1234', '5678', 'd', '{"$ref": {"key": "e"}}'),
+		('1234', '5678', 'e', '{"$ref": {"key": "f"}}'),
+		('1234', '5678', 'f', '1')`); err != nil {
+		t.Error(err)
+	}
+	var result any
+	if _, err := tags.Tag("1234", "5678", "a").Get(&result); !errors.Is(err, ErrReferenceCycle) {
+		t.Errorf("Expected ErrReferenceCycle, got %v", err)
+	}
+	if _, err := tags.Tag("1234", "5678", "c").Get(&result); !errors.Is(err, ErrReferenceDepth) {
+		t.Errorf("Expected ErrReferenceDepth, got %v", err)
+	}
+	if _, err := tags.Tag("1234", "5678", "d").Get(&result); err != nil {
+		t.Error(err)
+	}
+
+	// References are not resolved unless enabled.
+	var raw json.RawMessage
+	if _, err := NewTagsEngine(db).Tag("1234", "5678", "d").Get(&raw); err != nil {
+		t.Error(err)
+	}
+	if string(raw) != `{"$ref": {"key": "e"}}` {
+		t.Errorf("Expected reference not to be resolved, was %s", raw)
+	}
+}
+
+func TestReferencesDisallowedUniverse(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithUniverses("1234"), WithReferences(2))
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('secrets', 'bot', 'token', '"hunter2"')`); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "5678", "token").Set(Ref("secrets", "bot", "token")); err != nil {
+		t.Error(err)
+	}
+	var token string
+	if _, err := tags.Tag("1234", "5678", "token").Get(&token); !errors.Is(err, ErrUnknownUniverse) || token != "" {
+		t.Errorf("Expected the reference to a disallowed universe to fail, got %q (%v)", token, err)
+	}
+}
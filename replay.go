@@ -0,0 +1,140 @@
+package tango
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+var (
+	replayChanges = `SELECT universe, entity, key, value, at, deleted FROM ({changes})
+	WHERE (at, universe, entity, key) > (?, ?, ?, ?) AND substr(key, 1, length(?)) = ?
+	ORDER BY at, universe, entity, key LIMIT ?`
+	replayTags       = `SELECT universe, entity, key, value, updated_at AS at, 0 AS deleted FROM {table}`
+	replayTombstones = ` UNION ALL SELECT universe, entity, key, NULL, deleted_at, 1 FROM {table}_tombstones t
+	WHERE NOT EXISTS (SELECT 1 FROM {table} WHERE universe = t.universe AND entity = t.entity AND key = t.key)`
+)
+
+// replayPage is the number of changes read at once by Replay.
+const replayPage = 500
+
+// cursorSeparator separates the fields of the position of an event.
+const cursorSeparator = "\x00"
+
+// Cursor returns the position of the event, which can be persisted by the
+// subscriber and given to Replay to resume after a restart.
+func (event Event) Cursor() string {
+	at := event.At.UTC().Format(time.RFC3339Nano)
+	return encodeCursor(strings.Join([]string{at, event.Universe, event.Entity, event.Key}, cursorSeparator))
+}
+
+// parseEventCursor returns the position pointed by a cursor returned by
+// Event.Cursor, as an event holding the time and the address of the tag.
+func parseEventCursor(cursor string) (Event, error) {
+	position, err := decodeCursor(cursor)
+	if err != nil {
+		return Event{}, err
+	}
+	fields := strings.Split(position, cursorSeparator)
+	at, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return Event{}, ErrInvalidCursor
+	}
+	switch len(fields) {
+	case 1:
+		// Older cursors only hold the time, and point after every change
+		// made at that time. Times are stored with nanosecond precision.
+		return Event{At: at.Add(time.Nanosecond)}, nil
+	case 4:
+		return Event{At: at, Universe: fields[1], Entity: fields[2], Key: fields[3]}, nil
+	}
+	return Event{}, ErrInvalidCursor
+}
+
+// Replay calls fn with the changes made after the position pointed by the
+// cursor that pass the filter, in the order they were made, so that a
+// subscriber that was not running can catch up with the changes it missed.
+// The first replay is requested using an empty cursor, and the cursor of any
+// event received from Subscribe can be used to resume after it. It returns
+// the cursor of the last event given to fn, or the given cursor if there
+// were none, and it stops at the first error returned by fn. Changes are
+// read in pages, so they are never loaded into memory all at once.
+//
+// Changes are read from the tracking columns and the tombstones, so the
+// engine must be configured using WithTracking, and deletions are only
+// replayed if it is configured using WithTombstones too. Since only the last
+// change of every tag is kept, a tag changed many times is replayed once,
+// and writes that do not record their time, such as bulk upserts and
+// restores, are not replayed. Times are taken when the transactions start
+// rather than when they commit, so writes committed out of order by
+// concurrent processes may be missed. To avoid missing events between the
+// replay and the subscription, Subscribe before calling Replay and skip the
+// events received twice.
+func (tags *Tags) Replay(ctx context.Context, cursor string, filter WatchFilter, fn func(Event) error) (string, error) {
+	if !tags.tracking {
+		return cursor, ErrTrackingDisabled
+	}
+	var after Event
+	if cursor != "" {
+		var err error
+		if after, err = parseEventCursor(cursor); err != nil {
+			return cursor, err
+		}
+	}
+
+	for {
+		events, err := tags.replayPage(ctx, after)
+		if err != nil {
+			return cursor, err
+		}
+		for _, event := range events {
+			if !filter.matches(event) {
+				continue
+			}
+			if err := tags.callHook("replay", func() error { return fn(event) }); err != nil {
+				return cursor, err
+			}
+			cursor = event.Cursor()
+		}
+		if len(events) < replayPage {
+			return cursor, nil
+		}
+		after = events[len(events)-1]
+	}
+}
+
+// replayPage reads the next page of changes after the given position, in
+// the order they were made. The page is read completely before returning,
+// so that the connection is released before the changes are handled.
+func (tags *Tags) replayPage(ctx context.Context, after Event) ([]Event, error) {
+	changes := replayTags
+	if tags.tombstones {
+		changes += replayTombstones
+	}
+	query := tags.sql(strings.Replace(replayChanges, "{changes}", changes, 1))
+	prefix := tags.keyPrefix
+	rs, err := tags.db.QueryContext(ctx, query, after.At.UTC(), after.Universe, after.Entity, prefix+after.Key, prefix, prefix, replayPage)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	events := []Event{}
+	for rs.Next() {
+		var event Event
+		var value sql.NullString
+		var deleted bool
+		if err := rs.Scan(&event.Universe, &event.Entity, &event.Key, &value, &event.At, &deleted); err != nil {
+			return nil, err
+		}
+		event.Key = strings.TrimPrefix(event.Key, prefix)
+		event.Op, event.Value = EventSet, json.RawMessage(value.String)
+		if deleted {
+			event.Op, event.Value = EventDelete, nil
+		}
+		events = append(events, event)
+	}
+	return events, rs.Err()
+}
@@ -0,0 +1,190 @@
+package tango
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestTagsReplay(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithTracking(), WithTombstones())
+	ctx := context.Background()
+
+	if err := tags.Tag("1234", "a", "points").Set(1); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "a", "lang").Set("es"); err != nil {
+		t.Error(err)
+	}
+	var events []Event
+	cursor, err := tags.Replay(ctx, "", WatchFilter{}, func(event Event) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(events) != 2 || events[0].Key != "points" || events[1].Key != "lang" {
+		t.Fatalf("Expected every change to be replayed in order, got %+v", events)
+	}
+	if cursor != events[1].Cursor() {
+		t.Errorf("Expected the cursor of the last event")
+	}
+
+	// Resuming only replays the changes made after the cursor.
+	if err := tags.Tag("1234", "a", "points").Delete(); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "b", "points").Set(2); err != nil {
+		t.Error(err)
+	}
+	events = nil
+	cursor, err = tags.Replay(ctx, cursor, WatchFilter{Key: "points"}, func(event Event) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events after the cursor, got %+v", events)
+	}
+	if events[0].Op != EventDelete || events[0].Entity != "a" || events[1].Op != EventSet || events[1].Entity != "b" {
+		t.Errorf("Unexpected events %+v", events)
+	}
+	if _, err := tags.Replay(ctx, cursor, WatchFilter{}, func(event Event) error {
+		t.Errorf("Expected nothing to replay, got %+v", event)
+		return nil
+	}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTagsReplayStops(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithTracking())
+	ctx := context.Background()
+	for _, key := range []string{"one", "two"} {
+		if err := tags.Tag("1234", "a", key).Set(1); err != nil {
+			t.Error(err)
+		}
+	}
+
+	failure := errors.New("projection failed")
+	var first Event
+	cursor, err := tags.Replay(ctx, "", WatchFilter{}, func(event Event) error {
+		if event.Key == "two" {
+			return failure
+		}
+		first = event
+		return nil
+	})
+	if !errors.Is(err, failure) || cursor != first.Cursor() {
+		t.Errorf("Expected the replay to stop after the first event, got %v", err)
+	}
+	if _, err := tags.Replay(ctx, "%", WatchFilter{}, nil); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+	if _, err := NewTagsEngine(db).Replay(ctx, "", WatchFilter{}, nil); !errors.Is(err, ErrTrackingDisabled) {
+		t.Errorf("Expected ErrTrackingDisabled, got %v", err)
+	}
+}
+
+func TestTagsReplayPages(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithTracking())
+	ctx := context.Background()
+
+	// Every key is written in the same transaction, so they all share the
+	// same time, including the ones at the edges of the pages.
+	keys := make([]string, replayPage+10)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%04d", i)
+	}
+	if err := tags.Update("1234", "a", keys, func(current map[string]json.RawMessage) (map[string]any, error) {
+		values := make(map[string]any, len(keys))
+		for _, key := range keys {
+			values[key] = 1
+		}
+		return values, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	cursor, err := tags.Replay(ctx, "", WatchFilter{}, func(event Event) error {
+		if seen[event.Key] {
+			t.Errorf("Expected %s to be replayed once", event.Key)
+		}
+		seen[event.Key] = true
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(seen) != len(keys) {
+		t.Errorf("Expected %d events, got %d", len(keys), len(seen))
+	}
+	if _, err := tags.Replay(ctx, cursor, WatchFilter{}, func(event Event) error {
+		t.Errorf("Expected nothing to replay, got %+v", event)
+		return nil
+	}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTagsReplayAfterEvent(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithTracking())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := tags.Watch(ctx, WatchFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tags.Tag("1234", "a", "points").Set(1); err != nil {
+		t.Error(err)
+	}
+	event := <-events
+	meta, _, err := tags.Tag("1234", "a", "points").Metadata()
+	if err != nil {
+		t.Error(err)
+	}
+	if !event.At.Equal(meta.UpdatedAt) {
+		t.Errorf("Expected the event to be at %v, was at %v", meta.UpdatedAt, event.At)
+	}
+
+	// The cursor of a live event resumes the replay right after it.
+	if err := tags.Tag("1234", "a", "lang").Set("es"); err != nil {
+		t.Error(err)
+	}
+	var replayed []Event
+	if _, err := tags.Replay(ctx, event.Cursor(), WatchFilter{}, func(event Event) error {
+		replayed = append(replayed, event)
+		return nil
+	}); err != nil {
+		t.Error(err)
+	}
+	if len(replayed) != 1 || replayed[0].Key != "lang" {
+		t.Errorf("Expected only lang to be replayed, got %+v", replayed)
+	}
+}
@@ -0,0 +1,68 @@
+package tango
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+)
+
+// jsonSchemaDraft is the version of JSON Schema used by WriteJSONSchema.
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchemaTypes maps the kinds of the settings into JSON Schema types.
+var jsonSchemaTypes = map[Kind]string{
+	KindNull:   "null",
+	KindBool:   "boolean",
+	KindNumber: "number",
+	KindString: "string",
+	KindArray:  "array",
+	KindObject: "object",
+}
+
+// WriteJSONSchema writes a JSON Schema describing the settings declared in
+// the manifest into the writer, so that dashboards can render settings forms
+// without handwritten definitions. Every setting is a property of an object
+// schema, with its type, default value, allowed values, range and
+// description. Sensitive settings are marked as write only. The settings
+// can also be encoded as JSON directly to get a simpler catalog.
+func (m *Manifest) WriteJSONSchema(w io.Writer) error {
+	properties := make(map[string]any, len(m.settings))
+	order := make([]string, 0, len(m.settings))
+	for _, setting := range m.settings {
+		property := make(map[string]any)
+		if t, ok := jsonSchemaTypes[setting.Kind]; ok {
+			property["type"] = t
+		}
+		if setting.Default != nil {
+			property["default"] = setting.Default
+		}
+		if len(setting.Enum) > 0 {
+			property["enum"] = setting.Enum
+		}
+		if setting.Range != nil {
+			if !math.IsInf(setting.Range.Min, 0) {
+				property["minimum"] = setting.Range.Min
+			}
+			if !math.IsInf(setting.Range.Max, 0) {
+				property["maximum"] = setting.Range.Max
+			}
+		}
+		if setting.Description != "" {
+			property["description"] = setting.Description
+		}
+		if setting.Sensitive {
+			property["writeOnly"] = true
+		}
+		properties[setting.Key] = property
+		order = append(order, setting.Key)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{
+		"$schema":              jsonSchemaDraft,
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": true,
+		"x-order":              order,
+	})
+}
@@ -0,0 +1,91 @@
+package tango
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestManifestWriteJSONSchema(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testManifest.WriteJSONSchema(&buf); err != nil {
+		t.Error(err)
+	}
+	var schema struct {
+		Type       string `json:"type"`
+		Properties map[string]struct {
+			Type        string   `json:"type"`
+			Default     any      `json:"default"`
+			Enum        []string `json:"enum"`
+			Minimum     *float64 `json:"minimum"`
+			Maximum     *float64 `json:"maximum"`
+			Description string   `json:"description"`
+			WriteOnly   bool     `json:"writeOnly"`
+		} `json:"properties"`
+		Order []string `json:"x-order"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.Type != "object" || len(schema.Properties) != 4 || len(schema.Order) != 4 || schema.Order[0] != "prefix" {
+		t.Errorf("Unexpected schema %s", buf.String())
+	}
+	prefix := schema.Properties["prefix"]
+	if prefix.Type != "string" || prefix.Default != "!" || prefix.Description != "Prefix of the commands" {
+		t.Errorf("Unexpected prefix schema %+v", prefix)
+	}
+	if level := schema.Properties["log_level"]; len(level.Enum) != 3 {
+		t.Errorf("Unexpected log_level schema %+v", level)
+	}
+	if xp := schema.Properties["xp_multiplier"]; xp.Type != "number" || xp.Minimum == nil || *xp.Maximum != 10 {
+		t.Errorf("Unexpected xp_multiplier schema %+v", xp)
+	}
+	if token := schema.Properties["token"]; !token.WriteOnly {
+		t.Errorf("Expected token to be write only")
+	}
+}
+
+func TestManifestCatalog(t *testing.T) {
+	raw, err := json.Marshal(testManifest.Settings()[0])
+	if err != nil {
+		t.Error(err)
+	}
+	expected := `{"key":"prefix","kind":"string","default":"!","description":"Prefix of the commands"}`
+	if string(raw) != expected {
+		t.Errorf("Expected %s, got %s", expected, raw)
+	}
+}
+
+func TestManifestOpenRange(t *testing.T) {
+	manifest := NewManifest(
+		Setting{Key: "cooldown", Kind: KindNumber, Range: &Range{Min: 0, Max: math.Inf(1)}},
+	)
+	var buf bytes.Buffer
+	if err := manifest.WriteJSONSchema(&buf); err != nil {
+		t.Error(err)
+	}
+	var schema struct {
+		Properties map[string]map[string]any `json:"properties"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &schema); err != nil {
+		t.Fatal(err)
+	}
+	cooldown := schema.Properties["cooldown"]
+	if _, ok := cooldown["maximum"]; ok || cooldown["minimum"] != 0.0 {
+		t.Errorf("Expected only the minimum of an open range, got %v", cooldown)
+	}
+
+	raw, err := json.Marshal(manifest.Settings())
+	if err != nil {
+		t.Error(err)
+	}
+	expected := `[{"key":"cooldown","kind":"number","range":{"min":0}}]`
+	if string(raw) != expected {
+		t.Errorf("Expected %s, got %s", expected, raw)
+	}
+	var decoded Range
+	if err := json.Unmarshal([]byte(`{"min":0}`), &decoded); err != nil || decoded.Min != 0 || !math.IsInf(decoded.Max, 1) {
+		t.Errorf("Expected the missing end to be infinite, got %+v (%v)", decoded, err)
+	}
+}
@@ -0,0 +1,162 @@
+package tango
+
+import (
+	"context"
+	"math"
+	"strings"
+)
+
+var (
+	scopeCount = `SELECT COUNT(*) FROM {table} WHERE substr(key, 1, length(?)) = ?`
+)
+
+// ScopeOptions restricts what the engine returned by ScopedWith can do with
+// the keys of its scope, so that a misbehaving module cannot exhaust the
+// storage or tamper with data it should only read.
+type ScopeOptions struct {
+	// ReadOnly makes every write to the scope fail with ErrReadOnly.
+	ReadOnly bool
+
+	// MaxTags is the number of tags that can be stored under the prefix
+	// of the scope, counting every universe and entity. Writes that would
+	// create more tags fail with ErrQuotaExceeded, although tags that are
+	// already set can still be overwritten. It is unlimited if zero.
+	MaxTags int
+
+	// WarnAt is the share of MaxTags, such as 0.8, at which OnQuotaWarning
+	// is called, so that the scope can be cleaned up or its quota raised
+	// before writes start failing. The hook is called when a write makes
+	// the scope reach the threshold, after the write is committed.
+	WarnAt         float64
+	OnQuotaWarning func(QuotaWarning)
+}
+
+// A QuotaWarning tells that a scope is about to exhaust its quota.
+type QuotaWarning struct {
+	// Prefix is the prefix of the scope.
+	Prefix string
+
+	// Universe is the universe of the write that reached the threshold.
+	Universe string
+
+	// Tags is the number of tags stored under the prefix, and MaxTags is
+	// the quota of the scope.
+	Tags    int
+	MaxTags int
+}
+
+// A scopeQuota limits the number of tags stored under a prefix.
+type scopeQuota struct {
+	prefix  string
+	maxTags int
+	warnAt  int
+	warn    func(QuotaWarning)
+}
+
+// Scoped returns a view of this engine whose keys are namespaced using the
+// given prefix, such as "pluginX:", so that modules sharing the same store
+// cannot collide with or read each other's keys. Tags created by the view
+// transparently store their keys with the prefix, and listing the tags of
+// an entity only returns the keys under the prefix, without it. Scopes can
+// be nested, in which case the prefixes are concatenated.
+//
+// Options that refer to keys, such as WithKind or WithAlias, match the key
+// as stored, including the prefix. Only the operations over tags and
+// tagbags are scoped: maintenance operations that work over the whole
+// store, such as snapshots, exports, imports or bulk operations, fail with
+// ErrScoped, so they must be run using the engine the scope comes from.
+func (tags *Tags) Scoped(prefix string) *Tags {
+	return tags.ScopedWith(prefix, ScopeOptions{})
+}
+
+// ScopedWith works like Scoped, but restricts the returned engine using the
+// given options. The restrictions of the scopes an engine is nested into
+// still apply, so a nested scope cannot escape them.
+func (tags *Tags) ScopedWith(prefix string, opts ScopeOptions) *Tags {
+	scoped := *tags
+	scoped.keyPrefix = tags.keyPrefix + prefix
+	scoped.readOnly = tags.readOnly || opts.ReadOnly
+	if opts.MaxTags > 0 {
+		quotas := make([]scopeQuota, len(tags.quotas), len(tags.quotas)+1)
+		copy(quotas, tags.quotas)
+		quota := scopeQuota{prefix: scoped.keyPrefix, maxTags: opts.MaxTags}
+		if opts.WarnAt > 0 && opts.OnQuotaWarning != nil {
+			quota.warnAt = int(math.Ceil(opts.WarnAt * float64(opts.MaxTags)))
+			quota.warn = opts.OnQuotaWarning
+		}
+		scoped.quotas = append(quotas, quota)
+	}
+	scoped.store = &scoped
+	for _, decorator := range scoped.decorators {
+		scoped.store = decorator(scoped.store)
+	}
+	return &scoped
+}
+
+// Prefix returns the prefix of the keys of the engine, which is empty
+// unless the engine has been returned by Scoped.
+func (tags *Tags) Prefix() string {
+	return tags.keyPrefix
+}
+
+// checkUnscoped returns ErrScoped if the engine has been returned by Scoped,
+// since maintenance operations cannot honour the prefix, the read only flag
+// or the quotas of the scope.
+func (tags *Tags) checkUnscoped() error {
+	if tags.keyPrefix != "" || tags.readOnly || len(tags.quotas) > 0 {
+		return ErrScoped
+	}
+	return nil
+}
+
+// unscope returns the keys that belong to the scope of the engine, without
+// the prefix.
+func (tags *Tags) unscope(keys []string) []string {
+	if tags.keyPrefix == "" || keys == nil {
+		return keys
+	}
+	result := []string{}
+	for _, key := range keys {
+		if strings.HasPrefix(key, tags.keyPrefix) {
+			result = append(result, strings.TrimPrefix(key, tags.keyPrefix))
+		}
+	}
+	return result
+}
+
+// checkQuota returns ErrQuotaExceeded if writing the tag would create a tag
+// beyond the quota of any of the scopes of the engine. If the tag makes a
+// scope reach its warning threshold, the warning is sent once the
+// transaction is committed.
+func (tag *Tag) checkQuota(tx *txn) error {
+	if len(tag.tags.quotas) == 0 {
+		return nil
+	}
+	if _, exists, err := tag.fetchKey(tx.ctx, tx, tag.key); err != nil || exists {
+		return err
+	}
+	for _, quota := range tag.tags.quotas {
+		count, err := tag.tags.countScope(tx.ctx, tx, quota.prefix)
+		if err != nil {
+			return err
+		}
+		if count >= quota.maxTags {
+			return ErrQuotaExceeded
+		}
+		if quota.warn != nil && count+1 == quota.warnAt {
+			warning := QuotaWarning{Prefix: quota.prefix, Universe: tag.universe, Tags: count + 1, MaxTags: quota.maxTags}
+			warn := quota.warn
+			tx.committed(func() {
+				tag.tags.notifyHook("quota warning", func() { warn(warning) })
+			})
+		}
+	}
+	return nil
+}
+
+// countScope returns the number of tags stored under the given prefix.
+func (tags *Tags) countScope(ctx context.Context, q querier, prefix string) (int, error) {
+	var count int
+	err := q.QueryRowContext(ctx, tags.sql(scopeCount), prefix, prefix).Scan(&count)
+	return count, err
+}
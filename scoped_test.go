@@ -0,0 +1,264 @@
+package tango
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTagsScoped(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	plugin := tags.Scoped("plugin:")
+
+	if err := tags.Tag("1234", "a", "points").Set(10); err != nil {
+		t.Error(err)
+	}
+	if err := plugin.Tag("1234", "a", "points").Set(20); err != nil {
+		t.Error(err)
+	}
+
+	var points int
+	if _, err := tags.Tag("1234", "a", "points").Get(&points); err != nil || points != 10 {
+		t.Errorf("Expected the unscoped points to be 10, was %d (%v)", points, err)
+	}
+	if _, err := plugin.Tag("1234", "a", "points").Get(&points); err != nil || points != 20 {
+		t.Errorf("Expected the scoped points to be 20, was %d (%v)", points, err)
+	}
+	if _, err := tags.Tag("1234", "a", "plugin:points").Get(&points); err != nil || points != 20 {
+		t.Errorf("Expected the scoped points to be stored with the prefix, was %d (%v)", points, err)
+	}
+
+	keys, err := plugin.TagBag("1234", "a").Tags()
+	if err != nil || !reflect.DeepEqual(keys, []string{"points"}) {
+		t.Errorf("Expected the scope to list its keys only, got %v (%v)", keys, err)
+	}
+	keys, _, err = plugin.TagBag("1234", "a").TagsPage("", 10)
+	if err != nil || !reflect.DeepEqual(keys, []string{"points"}) {
+		t.Errorf("Expected the scope to page its keys only, got %v (%v)", keys, err)
+	}
+	var buf bytes.Buffer
+	if err := plugin.TagBag("1234", "a").WriteJSON(&buf); err != nil || strings.TrimSpace(buf.String()) != `{"points":20}` {
+		t.Errorf("Expected the scope to export its keys only, got %s (%v)", buf.String(), err)
+	}
+
+	if err := plugin.Tag("1234", "a", "points").Delete(); err != nil {
+		t.Error(err)
+	}
+	if exists, err := tags.Tag("1234", "a", "points").Get(&points); err != nil || !exists {
+		t.Errorf("Expected the unscoped points to be kept (%v)", err)
+	}
+}
+
+func TestTagsScopedNested(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	nested := tags.Scoped("plugin:").Scoped("cache:")
+	if nested.Prefix() != "plugin:cache:" {
+		t.Errorf("Expected the prefixes to be concatenated, got %s", nested.Prefix())
+	}
+	if err := nested.Tag("1234", "a", "tmp").Set(true); err != nil {
+		t.Error(err)
+	}
+	if removed, err := tags.Scoped("plugin:").TagBag("1234", "a").DeletePrefix("cache:"); err != nil || removed != 1 {
+		t.Errorf("Expected the nested tag to be removed, removed %d (%v)", removed, err)
+	}
+}
+
+func TestTagsScopedReadOnly(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	if err := tags.Tag("1234", "a", "core:prefix").Set("!"); err != nil {
+		t.Error(err)
+	}
+	core := tags.ScopedWith("core:", ScopeOptions{ReadOnly: true})
+
+	var prefix string
+	if _, err := core.Tag("1234", "a", "prefix").Get(&prefix); err != nil || prefix != "!" {
+		t.Errorf("Expected the read only scope to read, got %s (%v)", prefix, err)
+	}
+	if err := core.Tag("1234", "a", "prefix").Set("?"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly when setting, got %v", err)
+	}
+	if err := core.Tag("1234", "a", "prefix").Delete(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly when deleting, got %v", err)
+	}
+	if _, err := core.TagBag("1234", "a").DeletePrefix(""); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly when deleting a prefix, got %v", err)
+	}
+	if err := core.Scoped("nested:").Tag("1234", "a", "prefix").Set("?"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected nested scopes to be read only, got %v", err)
+	}
+}
+
+func TestTagsScopedQuota(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	plugin := tags.ScopedWith("plugin:", ScopeOptions{MaxTags: 2})
+
+	if err := plugin.Tag("1234", "a", "one").Set(1); err != nil {
+		t.Error(err)
+	}
+	if err := plugin.Tag("4321", "b", "two").Set(2); err != nil {
+		t.Error(err)
+	}
+	if err := plugin.Tag("1234", "a", "three").Set(3); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if err := plugin.Tag("1234", "a", "one").Set(11); err != nil {
+		t.Errorf("Expected existing tags to be overwritten, got %v", err)
+	}
+	if err := plugin.Scoped("nested:").Tag("1234", "a", "three").Set(3); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Expected nested scopes to count towards the quota, got %v", err)
+	}
+	if err := tags.Tag("1234", "a", "three").Set(3); err != nil {
+		t.Errorf("Expected the quota not to apply outside of the scope, got %v", err)
+	}
+	if err := plugin.Tag("1234", "a", "one").Delete(); err != nil {
+		t.Error(err)
+	}
+	if err := plugin.Tag("1234", "a", "three").Set(3); err != nil {
+		t.Errorf("Expected room for a new tag after deleting one, got %v", err)
+	}
+}
+
+func TestTagsScopedQuotaWarning(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	var warnings []QuotaWarning
+	plugin := tags.ScopedWith("plugin:", ScopeOptions{
+		MaxTags:        5,
+		WarnAt:         0.6,
+		OnQuotaWarning: func(w QuotaWarning) { warnings = append(warnings, w) },
+	})
+
+	for _, key := range []string{"one", "two", "three", "four"} {
+		if err := plugin.Tag("1234", "a", key).Set(1); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := plugin.Tag("1234", "a", "three").Set(3); err != nil {
+		t.Error(err)
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("Expected a single warning, got %+v", warnings)
+	}
+	if w := warnings[0]; w.Prefix != "plugin:" || w.Universe != "1234" || w.Tags != 3 || w.MaxTags != 5 {
+		t.Errorf("Unexpected warning %+v", w)
+	}
+
+	// Writes that are rolled back do not warn.
+	if _, err := db.Exec("DELETE FROM tags"); err != nil {
+		t.Error(err)
+	}
+	warnings = nil
+	strict := tags.ScopedWith("plugin:", ScopeOptions{
+		MaxTags:        3,
+		WarnAt:         1,
+		OnQuotaWarning: func(w QuotaWarning) { warnings = append(warnings, w) },
+	})
+	for _, key := range []string{"one", "two"} {
+		if err := strict.Tag("1234", "a", key).Set(1); err != nil {
+			t.Error(err)
+		}
+	}
+	err = strict.Update("1234", "a", nil, func(map[string]json.RawMessage) (map[string]any, error) {
+		return map[string]any{"three": 3, "four": 4}, nil
+	})
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warning for a rolled back write, got %+v", warnings)
+	}
+}
+
+func TestTagsScopedMaintenance(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	if err := tags.Tag("1234", "a", "points").Set(10); err != nil {
+		t.Error(err)
+	}
+	var snapshot bytes.Buffer
+	if err := tags.Snapshot(context.Background(), &snapshot, SnapshotOptions{}); err != nil {
+		t.Error(err)
+	}
+
+	ctx := context.Background()
+	records := []Record{{Universe: "1234", Entity: "a", Key: "points", Value: json.RawMessage("99")}}
+	scopes := map[string]*Tags{
+		"prefix":    tags.Scoped("plugin:"),
+		"read only": tags.ScopedWith("", ScopeOptions{ReadOnly: true}),
+		"quota":     tags.ScopedWith("", ScopeOptions{MaxTags: 10}),
+	}
+	for scope, scoped := range scopes {
+		ops := map[string]func() error{
+			"Analyze":  func() error { _, err := scoped.Analyze(ctx); return err },
+			"Usage":    func() error { _, err := scoped.Usage(ctx, 10); return err },
+			"Snapshot": func() error { return scoped.Snapshot(ctx, io.Discard, SnapshotOptions{}) },
+			"Restore": func() error {
+				_, _, err := scoped.Restore(ctx, bytes.NewReader(snapshot.Bytes()), ImportOptions{})
+				return err
+			},
+			"SnapshotTo":     func() error { return scoped.SnapshotTo(ctx, t.TempDir()+"/copy.db") },
+			"BulkUpsert":     func() error { _, err := scoped.BulkUpsert(ctx, records, BulkOptions{}); return err },
+			"DeleteUniverse": func() error { _, err := scoped.DeleteUniverse(ctx, "1234", BulkOptions{}); return err },
+			"ExportChangedSince": func() error {
+				_, err := scoped.ExportChangedSince(ctx, time.Time{}, io.Discard)
+				return err
+			},
+			"ApplyChanges": func() error {
+				_, err := scoped.ApplyChanges(ctx, strings.NewReader(""))
+				return err
+			},
+			"PruneTombstones": func() error { _, err := scoped.PruneTombstones(ctx, time.Now()); return err },
+			"RestoreToTime": func() error {
+				_, err := scoped.RestoreToTime(ctx, "1234", "5678", time.Now(), bytes.NewReader(snapshot.Bytes()), nil, ImportOptions{})
+				return err
+			},
+			"Conform":           func() error { _, err := scoped.Conform(ctx, "1234", ConformOptions{}); return err },
+			"ValidateManifest":  func() error { _, err := scoped.ValidateManifest(ctx, 10); return err },
+			"WriteUniverseJSON": func() error { return scoped.WriteUniverseJSON(ctx, "1234", io.Discard) },
+			"ReadUniverseJSON": func() error {
+				_, err := scoped.ReadUniverseJSON(ctx, "1234", strings.NewReader(""), ImportOptions{})
+				return err
+			},
+			"RebuildSummaries": func() error { return scoped.RebuildSummaries(ctx, "1234") },
+			"UnusedKeys":       func() error { _, err := scoped.UnusedKeys(ctx, time.Now()); return err },
+		}
+		for name, op := range ops {
+			if err := op(); !errors.Is(err, ErrScoped) {
+				t.Errorf("Expected %s to fail with ErrScoped in a %s scope, got %v", name, scope, err)
+			}
+		}
+	}
+
+	var points int
+	if _, err := tags.Tag("1234", "a", "points").Get(&points); err != nil || points != 10 {
+		t.Errorf("Expected the store to be left untouched, points were %d (%v)", points, err)
+	}
+}
@@ -0,0 +1,103 @@
+package tango
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// A Mismatch describes a read whose result was different in the shadow
+// store than in the primary store.
+type Mismatch struct {
+	Op       string
+	Universe string
+	Entity   string
+	Key      string
+
+	// Primary and Shadow are the results of the read in each store. For
+	// tags, they are the values of the tag, or nil if the tag is not set.
+	// For lists, they are the JSON encoded list of keys.
+	Primary json.RawMessage
+	Shadow  json.RawMessage
+
+	// Err is the error returned by the shadow store, if it failed.
+	Err error
+}
+
+// A shadowStore performs the reads of an engine against another store to
+// compare their results.
+type shadowStore struct {
+	tags   *Tags
+	store  TagStore
+	report func(Mismatch)
+}
+
+// WithShadowStore makes every read of the engine also be performed against
+// the given shadow store, such as an engine running over a new database the
+// data is being migrated to. Reads are always served from the engine, and
+// the given function is called whenever the shadow store returns something
+// different or fails. Writes are not performed against the shadow store.
+// Shadow reads happen in the same goroutine once the engine has read from
+// the database, so they add to the latency of every read. The comparison is
+// not a decorator, so the rest of the operations work as usual.
+func WithShadowStore(shadow TagStore, report func(Mismatch)) Option {
+	return func(tags *Tags) {
+		tags.shadow = &shadowStore{tags: tags, store: shadow, report: report}
+	}
+}
+
+// compareTag reads the tag from the shadow store and reports whether its
+// result is different from the one read from the primary store.
+func (s *shadowStore) compareTag(ctx context.Context, universe, entity, key string, raw json.RawMessage, exists bool) {
+	shadow, shadowExists, shadowErr := s.store.GetTag(ctx, universe, entity, key)
+	if shadowErr != nil || exists != shadowExists || (exists && !sameJSON(raw, shadow)) {
+		s.mismatch(Mismatch{Op: "get", Universe: universe, Entity: entity, Key: key, Primary: raw, Shadow: shadow, Err: shadowErr})
+	}
+}
+
+// compareKeys lists the tags from the shadow store and reports whether its
+// result is different from the one listed from the primary store.
+func (s *shadowStore) compareKeys(ctx context.Context, universe, entity string, keys []string) {
+	shadow, shadowErr := s.store.ListTags(ctx, universe, entity)
+	if shadowErr != nil || !sameKeys(keys, shadow) {
+		primaryJSON, _ := json.Marshal(keys)
+		var shadowJSON json.RawMessage
+		if shadowErr == nil {
+			shadowJSON, _ = json.Marshal(shadow)
+		}
+		s.mismatch(Mismatch{Op: "list", Universe: universe, Entity: entity, Primary: primaryJSON, Shadow: shadowJSON, Err: shadowErr})
+	}
+}
+
+func (s *shadowStore) mismatch(m Mismatch) {
+	s.tags.notifyHook("shadow report", func() {
+		s.report(m)
+	})
+}
+
+// sameJSON returns whether two JSON representations hold the same value.
+func sameJSON(a, b json.RawMessage) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return string(a) == string(b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// sameKeys returns whether two lists hold the same keys in any order.
+func sameKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package tango
+
+import "testing"
+
+func TestShadowStore(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	// Both engines share the database, but they use different tables.
+	if _, err := db.Exec(`CREATE TABLE shadow AS SELECT * FROM tags WHERE 0;
+		CREATE UNIQUE INDEX shadow_id ON shadow(universe, entity, key)`); err != nil {
+		t.Error(err)
+	}
+	shadow := NewTagsEngine(db, WithTable("shadow"))
+	var mismatches []Mismatch
+	tags := NewTagsEngine(db, WithShadowStore(shadow, func(m Mismatch) {
+		mismatches = append(mismatches, m)
+	}))
+
+	if err := tags.Tag("1234", "5678", "points").Set(10); err != nil {
+		t.Error(err)
+	}
+	if err := shadow.Tag("1234", "5678", "points").Set(10.0); err != nil {
+		t.Error(err)
+	}
+	var points int
+	if _, err := tags.Tag("1234", "5678", "points").Get(&points); err != nil || points != 10 {
+		t.Errorf("Expected points to be 10, was %d (%v)", points, err)
+	}
+	if len(mismatches) != 0 {
+		t.Errorf("Expected no mismatches, got %+v", mismatches)
+	}
+
+	if err := tags.Tag("1234", "5678", "prefix").Set("!"); err != nil {
+		t.Error(err)
+	}
+	var prefix string
+	if _, err := tags.Tag("1234", "5678", "prefix").Get(&prefix); err != nil || prefix != "!" {
+		t.Errorf("Expected prefix to be served from the primary, was `%s` (%v)", prefix, err)
+	}
+	if _, err := tags.TagBag("1234", "5678").Tags(); err != nil {
+		t.Error(err)
+	}
+	if len(mismatches) != 2 {
+		t.Fatalf("Expected 2 mismatches, got %+v", mismatches)
+	}
+	if m := mismatches[0]; m.Op != "get" || m.Key != "prefix" || string(m.Primary) != `"!"` || m.Shadow != nil {
+		t.Errorf("Unexpected mismatch %+v", m)
+	}
+	if m := mismatches[1]; m.Op != "list" || string(m.Shadow) != `["points"]` {
+		t.Errorf("Unexpected mismatch %+v", m)
+	}
+}
+
+func TestShadowStoreAtomic(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithShadowStore(NewTagsEngine(db), func(m Mismatch) {
+		t.Errorf("Unexpected mismatch %+v", m)
+	}))
+
+	// The comparison does not disable the operations that bypass the store.
+	tag := tags.Tag("1234", "5678", "points")
+	if stored, err := tag.SetIfAbsent(10); err != nil || !stored {
+		t.Errorf("Expected points to be stored (%v)", err)
+	}
+	if exists, err := tag.Exists(); err != nil || !exists {
+		t.Errorf("Expected points to exist (%v)", err)
+	}
+	var old int
+	if existed, err := tag.Swap(20, &old); err != nil || !existed || old != 10 {
+		t.Errorf("Expected points to be swapped from 10, was %d (%v)", old, err)
+	}
+	has, err := tags.TagBag("1234", "5678").HasMany([]string{"points", "lang"})
+	if err != nil || !has["points"] || has["lang"] {
+		t.Errorf("Unexpected result %v (%v)", has, err)
+	}
+	if err := tags.Tag("1234", "5678", "lang").SetOnce("es"); err != nil {
+		t.Error(err)
+	}
+	var points int
+	if _, err := tag.Get(&points); err != nil || points != 20 {
+		t.Errorf("Expected points to be 20, was %d (%v)", points, err)
+	}
+}
@@ -0,0 +1,96 @@
+package tango
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// redacted replaces the values of the tags in the arguments reported in
+// an OpInfo, since they may hold sensitive information.
+const redacted = "[redacted]"
+
+// OpInfo describes an operation over a tag that took longer than the
+// threshold configured using WithSlowOpThreshold.
+type OpInfo struct {
+	Op       string
+	Universe string
+	Entity   string
+	Key      string
+
+	// SQL is the last statement run by the operation over the tags, and
+	// Args are its arguments. The values of the tags are redacted. They
+	// are empty if the operation failed before running any statement.
+	SQL  string
+	Args []any
+
+	Duration time.Duration
+}
+
+// slowOp holds the threshold and the function to report slow operations.
+type slowOp struct {
+	threshold time.Duration
+	report    func(OpInfo)
+}
+
+// opTrace records the statements run by an operation being timed.
+type opTrace struct {
+	mu   sync.Mutex
+	sql  string
+	args []any
+}
+
+// traceKey is the key of the context holding the opTrace of an operation.
+type traceKey struct{}
+
+// trace starts timing an operation over a tag. The returned context should
+// be used to run the statements of the operation, and the returned function
+// should be called when the operation ends, and it will report the
+// operation if it was slower than the threshold configured for the engine.
+func (tags *Tags) trace(ctx context.Context, op string, tag *Tag) (context.Context, func()) {
+	if tags.slowOp == nil {
+		return ctx, func() {}
+	}
+	tr := &opTrace{}
+	ctx = context.WithValue(ctx, traceKey{}, tr)
+	start := time.Now()
+	return ctx, func() {
+		elapsed := time.Since(start)
+		if elapsed < tags.slowOp.threshold {
+			return
+		}
+		tr.mu.Lock()
+		info := OpInfo{
+			Op:       op,
+			Universe: tag.universe,
+			Entity:   tag.entity,
+			Key:      tag.key,
+			SQL:      tr.sql,
+			Args:     tr.args,
+			Duration: elapsed,
+		}
+		tr.mu.Unlock()
+		tags.notifyHook("slow operation report", func() {
+			tags.slowOp.report(info)
+		})
+	}
+}
+
+// traceStatement records the statement about to be run as part of the
+// operation being timed using the given context, if any. Values of tags
+// given as arguments should be replaced by redacted.
+func traceStatement(ctx context.Context, query string, args ...any) {
+	if tr, ok := ctx.Value(traceKey{}).(*opTrace); ok {
+		tr.mu.Lock()
+		tr.sql, tr.args = query, args
+		tr.mu.Unlock()
+	}
+}
+
+// redactValue returns a copy of the arguments of a statement writing a tag,
+// whose fourth argument is the value of the tag, with the value redacted.
+func redactValue(args []any) []any {
+	redactedArgs := append([]any(nil), args...)
+	redactedArgs[3] = redacted
+	return redactedArgs
+}
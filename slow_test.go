@@ -0,0 +1,65 @@
+package tango
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlowOpThreshold(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	// Every operation takes at least zero, so every operation is reported.
+	var reports []OpInfo
+	tags := NewTagsEngine(db, WithTracking(), WithSlowOpThreshold(0, func(info OpInfo) {
+		reports = append(reports, info)
+	}))
+
+	if err := tags.Tag("1234", "5678", "token").Set("secret"); err != nil {
+		t.Error(err)
+	}
+	var result string
+	if _, err := tags.Tag("1234", "5678", "token").Get(&result); err != nil {
+		t.Error(err)
+	}
+
+	if len(reports) != 2 {
+		t.Fatalf("Expected 2 reports, got %d", len(reports))
+	}
+	set := reports[0]
+	if set.Op != "set" || set.Universe != "1234" || set.Entity != "5678" || set.Key != "token" {
+		t.Errorf("Unexpected report %+v", set)
+	}
+	if !strings.Contains(set.SQL, "INSERT INTO tags") || !strings.Contains(set.SQL, "updated_by") {
+		t.Errorf("Expected report to include the executed statement, got %s", set.SQL)
+	}
+	if len(set.Args) != 6 || set.Args[3] != redacted {
+		t.Errorf("Expected value to be redacted, got %v", set.Args)
+	}
+	get := reports[1]
+	if get.Op != "get" || !strings.HasPrefix(get.SQL, "SELECT value FROM tags") || len(get.Args) != 3 || get.Args[2] != "token" {
+		t.Errorf("Unexpected report %+v", get)
+	}
+}
+
+func TestSlowOpThresholdNotReached(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	reported := false
+	tags := NewTagsEngine(db, WithSlowOpThreshold(1<<62, func(info OpInfo) {
+		reported = true
+	}))
+	if err := tags.Tag("1234", "5678", "token").Set("secret"); err != nil {
+		t.Error(err)
+	}
+	if reported {
+		t.Errorf("Expected fast operation not to be reported")
+	}
+}
@@ -0,0 +1,398 @@
+package tango
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// A Record is a single tag of the store, addressed by its full key.
+type Record struct {
+	Universe string
+	Entity   string
+	Key      string
+	Value    json.RawMessage
+}
+
+// snapshotMagic is written at the beginning of every snapshot so that
+// snapshots can be told apart from other files.
+var snapshotMagic = [8]byte{'T', 'A', 'N', 'G', 'O', 'S', 'N', 'P'}
+
+const (
+	// SnapshotVersion is the version of the snapshot format written by
+	// this version of the package. Snapshots written using an older
+	// version of the format can still be restored. Version 2 added the
+	// checksums, and version 3 authenticates the header along with the
+	// encrypted body.
+	SnapshotVersion = 3
+
+	// maxSnapshotHeader is the largest JSON encoded header accepted when
+	// reading a snapshot, so that corrupted lengths do not exhaust memory.
+	maxSnapshotHeader = 1 << 20
+
+	// snapshotGzip is set in the flags when the body is gzip compressed.
+	snapshotGzip = 1 << 0
+
+	// snapshotEncrypted is set in the flags when the body is encrypted.
+	snapshotEncrypted = 1 << 1
+)
+
+var (
+	snapshotAll   = `SELECT universe, entity, key, value FROM {table} ORDER BY universe, entity, key`
+	snapshotCount = `SELECT COUNT(*) FROM {table}`
+)
+
+// A snapshotEntry is every value of the body of a snapshot since version 2.
+// Every record is followed by its checksum, and the body ends with an entry
+// without record whose checksum covers every record of the snapshot.
+type snapshotEntry struct {
+	Record   *Record
+	Checksum uint32
+	Count    int
+}
+
+// checksum updates the CRC-32 checksum crc with the contents of the record.
+func (record *Record) checksum(crc uint32) uint32 {
+	for _, field := range []string{record.Universe, record.Entity, record.Key} {
+		crc = crc32.Update(crc, crc32.IEEETable, []byte(field))
+		crc = crc32.Update(crc, crc32.IEEETable, []byte{0})
+	}
+	return crc32.Update(crc, crc32.IEEETable, record.Value)
+}
+
+// SnapshotOptions tune how a snapshot is written.
+type SnapshotOptions struct {
+	// Compress the body of the snapshot using gzip.
+	Compress bool
+
+	// Metadata is arbitrary information stored in the snapshot header,
+	// such as the schema version of the application.
+	Metadata map[string]string
+
+	// Progress, if set, receives a report every thousand records.
+	Progress Progress
+
+	// EncryptionKey, if set, is used to encrypt the body of the snapshot
+	// as done by NewEncryptWriter. The same key has to be given to
+	// Restore using ImportOptions.
+	EncryptionKey []byte
+}
+
+// A SnapshotHeader holds the information written at the beginning of a
+// snapshot, before the records.
+type SnapshotHeader struct {
+	Version    int               `json:"-"`
+	Compressed bool              `json:"-"`
+	Encrypted  bool              `json:"-"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// Snapshot writes a full backup of the store into the writer. A snapshot
+// is made of a binary header, followed by a JSON encoded SnapshotHeader,
+// followed by a body of gob encoded records that may be compressed. Every
+// record carries a checksum, and the body ends with a checksum of every
+// record, which are verified when restoring the snapshot. The body may be
+// encrypted too, in which case only the header is readable, although it is
+// authenticated along with the body so that it cannot be altered. Since
+// the labels of the universes are kept in the store, they are part of the
+// snapshot too. Cancelling the context aborts the snapshot, leaving an
+// incomplete snapshot in the writer.
+func (tags *Tags) Snapshot(ctx context.Context, w io.Writer, opts SnapshotOptions) error {
+	if err := tags.checkUnscoped(); err != nil {
+		return err
+	}
+	header := SnapshotHeader{
+		Version:    SnapshotVersion,
+		Compressed: opts.Compress,
+		Encrypted:  opts.EncryptionKey != nil,
+		CreatedAt:  time.Now().UTC(),
+		Metadata:   opts.Metadata,
+	}
+	raw, err := writeSnapshotHeader(w, &header)
+	if err != nil {
+		return err
+	}
+
+	body := w
+	var ew io.WriteCloser
+	if opts.EncryptionKey != nil {
+		if ew, err = newEncryptWriter(w, opts.EncryptionKey, raw); err != nil {
+			return err
+		}
+		body = ew
+	}
+	var zw *gzip.Writer
+	if opts.Compress {
+		zw = gzip.NewWriter(body)
+		body = zw
+	}
+	enc := gob.NewEncoder(body)
+
+	var tracker *progressTracker
+	if opts.Progress != nil {
+		var total int
+		if err := tags.db.QueryRowContext(ctx, tags.sql(snapshotCount)).Scan(&total); err != nil {
+			return err
+		}
+		tracker = tags.track(opts.Progress, "snapshot", total)
+	}
+	rs, err := tags.db.QueryContext(ctx, tags.sql(snapshotAll))
+	if err != nil {
+		return err
+	}
+	defer rs.Close()
+	written := 0
+	var crc uint32
+	for rs.Next() {
+		var record Record
+		var value string
+		if err := rs.Scan(&record.Universe, &record.Entity, &record.Key, &value); err != nil {
+			return err
+		}
+		record.Value = json.RawMessage(value)
+		crc = record.checksum(crc)
+		if err := enc.Encode(&snapshotEntry{Record: &record, Checksum: record.checksum(0)}); err != nil {
+			return err
+		}
+		if written++; written%progressEvery == 0 {
+			tracker.report(written)
+		}
+	}
+	if err := rs.Err(); err != nil {
+		return err
+	}
+	if err := enc.Encode(&snapshotEntry{Checksum: crc, Count: written}); err != nil {
+		return err
+	}
+	if written%progressEvery != 0 {
+		tracker.report(written)
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+	if ew != nil {
+		return ew.Close()
+	}
+	return nil
+}
+
+// Restore reads a snapshot from the reader and upserts every record into
+// the store, as part of a single transaction. It returns the header of the
+// snapshot. Hooks such as validators are not called for restored records,
+// and summaries are not updated, so they should be rebuilt after restoring.
+// Tags that are already set are handled according to the strategy given in
+// the options, and the returned summary tells what was done. Cancelling
+// the context or failing because of a conflict rolls back the whole
+// restore.
+func (tags *Tags) Restore(ctx context.Context, r io.Reader, opts ImportOptions) (*SnapshotHeader, ImportSummary, error) {
+	if err := tags.checkUnscoped(); err != nil {
+		return nil, ImportSummary{}, err
+	}
+	var header *SnapshotHeader
+	var summary ImportSummary
+	err := tags.transaction(ctx, func(tx *txn) error {
+		im, err := tags.newImporter(tx, opts)
+		if err != nil {
+			return err
+		}
+		defer im.close()
+		defer func() { summary = im.summary }()
+		header, err = readSnapshot(r, opts, func(record *Record) error {
+			return im.write(record.Universe, record.Entity, record.Key, record.Value)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, ImportSummary{}, err
+	}
+	tags.cache.clear()
+	return header, summary, nil
+}
+
+// readSnapshot reads the header of a snapshot from the reader, and then
+// calls fn for every record of the body, decrypting and decompressing it
+// if needed.
+func readSnapshot(r io.Reader, opts ImportOptions, fn func(*Record) error) (*SnapshotHeader, error) {
+	br := bufio.NewReader(r)
+	header, raw, err := readSnapshotHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	var body io.Reader = br
+	if header.Encrypted {
+		if opts.EncryptionKey == nil {
+			return nil, ErrDecryption
+		}
+		// Snapshots older than version 3 do not authenticate the header.
+		if header.Version < 3 {
+			raw = nil
+		}
+		if body, err = newDecryptReader(br, opts.EncryptionKey, raw); err != nil {
+			return nil, err
+		}
+	}
+	if header.Compressed {
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		body = zr
+	}
+	dec := gob.NewDecoder(body)
+	if err := readSnapshotBody(dec, header.Version, !opts.NoVerify, fn); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// readSnapshotBody decodes the records of the body of a snapshot written
+// using the given version of the format, calling fn for every record. If
+// verify is set, the checksums are verified, and a snapshot whose body is
+// truncated or whose checksums do not match fails with ErrChecksumMismatch.
+func readSnapshotBody(dec *gob.Decoder, version int, verify bool, fn func(*Record) error) error {
+	if version < 2 {
+		for {
+			var record Record
+			if err := dec.Decode(&record); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if err := fn(&record); err != nil {
+				return err
+			}
+		}
+	}
+
+	var crc uint32
+	count := 0
+	for {
+		var entry snapshotEntry
+		if err := dec.Decode(&entry); err == io.EOF {
+			if verify {
+				return fmt.Errorf("%w: snapshot is truncated", ErrChecksumMismatch)
+			}
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if entry.Record == nil {
+			if verify && (entry.Checksum != crc || entry.Count != count) {
+				return fmt.Errorf("%w: snapshot checksum does not match", ErrChecksumMismatch)
+			}
+			return nil
+		}
+		record := entry.Record
+		if verify && entry.Checksum != record.checksum(0) {
+			return fmt.Errorf("%w: record %s/%s/%s", ErrChecksumMismatch, record.Universe, record.Entity, record.Key)
+		}
+		crc = record.checksum(crc)
+		count++
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// writeSnapshotHeader writes the magic, the format version, the flags and
+// the JSON encoded header, and returns the bytes written.
+func writeSnapshotHeader(w io.Writer, header *SnapshotHeader) ([]byte, error) {
+	meta, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	var flags uint8
+	if header.Compressed {
+		flags |= snapshotGzip
+	}
+	if header.Encrypted {
+		flags |= snapshotEncrypted
+	}
+	var raw bytes.Buffer
+	fields := []any{snapshotMagic, uint16(header.Version), flags, uint32(len(meta))}
+	for _, field := range fields {
+		if err := binary.Write(&raw, binary.BigEndian, field); err != nil {
+			return nil, err
+		}
+	}
+	raw.Write(meta)
+	if _, err := w.Write(raw.Bytes()); err != nil {
+		return nil, err
+	}
+	return raw.Bytes(), nil
+}
+
+// ReadSnapshotHeader reads the header of a snapshot from the reader, leaving
+// the reader positioned at the beginning of the body.
+func ReadSnapshotHeader(r io.Reader) (*SnapshotHeader, error) {
+	header, _, err := readSnapshotHeader(r)
+	return header, err
+}
+
+// readSnapshotHeader works like ReadSnapshotHeader, but also returns the
+// bytes of the header as they were read.
+func readSnapshotHeader(r io.Reader) (*SnapshotHeader, []byte, error) {
+	var raw bytes.Buffer
+	r = io.TeeReader(r, &raw)
+	var magic [8]byte
+	var version uint16
+	var flags uint8
+	var length uint32
+	fields := []any{&magic, &version, &flags, &length}
+	for _, field := range fields {
+		if err := binary.Read(r, binary.BigEndian, field); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, nil, ErrInvalidSnapshot
+			}
+			return nil, nil, err
+		}
+	}
+	if magic != snapshotMagic {
+		return nil, nil, ErrInvalidSnapshot
+	}
+	if version == 0 || version > SnapshotVersion {
+		return nil, nil, ErrUnsupportedSnapshot
+	}
+
+	if length > maxSnapshotHeader {
+		return nil, nil, fmt.Errorf("%w: header of %d bytes is too large", ErrInvalidSnapshot, length)
+	}
+	meta := make([]byte, length)
+	if _, err := io.ReadFull(r, meta); err != nil {
+		return nil, nil, ErrInvalidSnapshot
+	}
+	var header SnapshotHeader
+	if err := json.Unmarshal(meta, &header); err != nil {
+		return nil, nil, ErrInvalidSnapshot
+	}
+	header.Version = int(version)
+	header.Compressed = flags&snapshotGzip != 0
+	header.Encrypted = flags&snapshotEncrypted != 0
+	return &header, raw.Bytes(), nil
+}
+
+// SnapshotTo writes a consistent copy of the whole database into a new
+// database file at the given path, which must not exist, using SQLite's
+// VACUUM INTO. The copy can be opened read only by analytics jobs, so that
+// their queries do not compete with the live store for locks. Unlike
+// Snapshot, the copy includes every table of the database.
+func (tags *Tags) SnapshotTo(ctx context.Context, path string) error {
+	if err := tags.checkUnscoped(); err != nil {
+		return err
+	}
+	_, err := tags.db.ExecContext(ctx, `VACUUM INTO ?`, path)
+	return err
+}
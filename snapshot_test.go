@@ -0,0 +1,228 @@
+package tango
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		db, tags, err := prepareTagEngine()
+		if err != nil {
+			t.Error(err)
+		}
+
+		if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+			('1234', '5678', 'string', '"hello"'),
+			('1234', '5678', 'number', '14'),
+			('4321', '8765', 'object', '{"a":[1,2]}')`); err != nil {
+			t.Error(err)
+		}
+
+		var buf bytes.Buffer
+		opts := SnapshotOptions{Compress: compress, Metadata: map[string]string{"schema": "3"}}
+		if err := tags.Snapshot(context.Background(), &buf, opts); err != nil {
+			t.Error(err)
+		}
+		db.Close()
+
+		// Restore into a fresh database.
+		db, tags, err = prepareTagEngine()
+		if err != nil {
+			t.Error(err)
+		}
+		header, _, err := tags.Restore(context.Background(), &buf, ImportOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Version != SnapshotVersion || header.Compressed != compress || header.Metadata["schema"] != "3" {
+			t.Errorf("Unexpected header %+v", header)
+		}
+
+		var result map[string][]int
+		exists, err := tags.Tag("4321", "8765", "object").Get(&result)
+		if err != nil {
+			t.Error(err)
+		}
+		if !exists || len(result["a"]) != 2 {
+			t.Errorf("Expected object to be restored, got %v", result)
+		}
+		var count int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM tags`).Scan(&count); err != nil {
+			t.Error(err)
+		}
+		if count != 3 {
+			t.Errorf("Expected 3 records to be restored, got %d", count)
+		}
+		db.Close()
+	}
+}
+
+func TestSnapshotInvalid(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, _, err := tags.Restore(context.Background(), bytes.NewBufferString(`{"not":"a snapshot"}`), ImportOptions{}); err != ErrInvalidSnapshot {
+		t.Errorf("Expected ErrInvalidSnapshot, got %v", err)
+	}
+
+	future := append(snapshotMagic[:], 0xff, 0xff, 0, 0, 0, 0, 2, '{', '}')
+	if _, _, err := tags.Restore(context.Background(), bytes.NewBuffer(future), ImportOptions{}); err != ErrUnsupportedSnapshot {
+		t.Errorf("Expected ErrUnsupportedSnapshot, got %v", err)
+	}
+
+	huge := append(snapshotMagic[:], 0, SnapshotVersion, 0, 0xff, 0xff, 0xff, 0xff)
+	if _, err := ReadSnapshotHeader(bytes.NewBuffer(huge)); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("Expected ErrInvalidSnapshot for a huge header, got %v", err)
+	}
+}
+
+func TestSnapshotChecksums(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	if err := tags.Tag("1234", "5678", "string").Set("hello"); err != nil {
+		t.Error(err)
+	}
+	var buf bytes.Buffer
+	if err := tags.Snapshot(context.Background(), &buf, SnapshotOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	corrupted := bytes.Replace(buf.Bytes(), []byte("hello"), []byte("jello"), 1)
+
+	if _, _, err := tags.Restore(context.Background(), bytes.NewReader(corrupted), ImportOptions{}); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch, got %v", err)
+	}
+	var result string
+	if _, err := tags.Tag("1234", "5678", "string").Get(&result); err != nil || result != "hello" {
+		t.Errorf("Expected string to be kept as hello, was %s (%v)", result, err)
+	}
+
+	if _, _, err := tags.Restore(context.Background(), bytes.NewReader(corrupted), ImportOptions{NoVerify: true}); err != nil {
+		t.Error(err)
+	}
+	if _, err := tags.Tag("1234", "5678", "string").Get(&result); err != nil || result != "jello" {
+		t.Errorf("Expected string to be restored as jello, was %s (%v)", result, err)
+	}
+}
+
+func TestSnapshotEncrypted(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	if err := tags.Tag("1234", "5678", "string").Set("hello"); err != nil {
+		t.Error(err)
+	}
+	key := bytes.Repeat([]byte{7}, 16)
+	var buf bytes.Buffer
+	if err := tags.Snapshot(context.Background(), &buf, SnapshotOptions{Compress: true, EncryptionKey: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := tags.Restore(context.Background(), bytes.NewReader(buf.Bytes()), ImportOptions{}); !errors.Is(err, ErrDecryption) {
+		t.Errorf("Expected ErrDecryption, got %v", err)
+	}
+	header, summary, err := tags.Restore(context.Background(), bytes.NewReader(buf.Bytes()), ImportOptions{EncryptionKey: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !header.Encrypted || !header.Compressed || summary.Overwritten != 1 {
+		t.Errorf("Unexpected header %+v and summary %+v", header, summary)
+	}
+
+	// The header is authenticated along with the body, so it cannot be
+	// tampered with, such as to drop the compression flag.
+	tampered := append([]byte(nil), buf.Bytes()...)
+	tampered[len(snapshotMagic)+2] &^= snapshotGzip
+	if _, _, err := tags.Restore(context.Background(), bytes.NewReader(tampered), ImportOptions{EncryptionKey: key}); !errors.Is(err, ErrDecryption) {
+		t.Errorf("Expected ErrDecryption for a tampered header, got %v", err)
+	}
+}
+
+func TestSnapshotTruncated(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	// A snapshot whose body ends before the final checksum.
+	var buf bytes.Buffer
+	if _, err := writeSnapshotHeader(&buf, &SnapshotHeader{Version: SnapshotVersion}); err != nil {
+		t.Fatal(err)
+	}
+	record := Record{Universe: "1234", Entity: "5678", Key: "points", Value: json.RawMessage(`10`)}
+	if err := gob.NewEncoder(&buf).Encode(&snapshotEntry{Record: &record, Checksum: record.checksum(0)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := tags.Restore(context.Background(), bytes.NewReader(buf.Bytes()), ImportOptions{}); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch, got %v", err)
+	}
+}
+
+func TestSnapshotVersion1(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	// Snapshots written before checksums were added are still restored.
+	var buf bytes.Buffer
+	if _, err := writeSnapshotHeader(&buf, &SnapshotHeader{Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	record := Record{Universe: "1234", Entity: "5678", Key: "points", Value: json.RawMessage(`10`)}
+	if err := gob.NewEncoder(&buf).Encode(&record); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := tags.Restore(context.Background(), &buf, ImportOptions{}); err != nil {
+		t.Error(err)
+	}
+	var points int
+	if _, err := tags.Tag("1234", "5678", "points").Get(&points); err != nil || points != 10 {
+		t.Errorf("Expected points to be 10, was %d (%v)", points, err)
+	}
+}
+
+func TestSnapshotTo(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if err := tags.Tag("1234", "5678", "points").Set(10); err != nil {
+		t.Error(err)
+	}
+	path := filepath.Join(t.TempDir(), "copy.db")
+	if err := tags.SnapshotTo(context.Background(), path); err != nil {
+		t.Fatal(err)
+	}
+	if err := tags.SnapshotTo(context.Background(), path); err == nil {
+		t.Errorf("Expected snapshot not to overwrite existing files")
+	}
+
+	copyDB, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer copyDB.Close()
+	var points int
+	if _, err := NewTagsEngine(copyDB).Tag("1234", "5678", "points").Get(&points); err != nil || points != 10 {
+		t.Errorf("Expected points to be 10 in the copy, was %d (%v)", points, err)
+	}
+}
@@ -0,0 +1,258 @@
+package tango
+
+import (
+	"expvar"
+	"sort"
+	"sync"
+)
+
+// Stats are the counters of the operations made by an engine.
+type Stats struct {
+	// Ops is the number of operations made over the tags.
+	Ops int64 `json:"ops"`
+
+	// Errors is the number of operations that failed.
+	Errors int64 `json:"errors"`
+
+	// CacheHits and CacheMisses count the reads that were served from the
+	// cache and the reads that had to query the database, if the engine
+	// was configured with a cache.
+	CacheHits   int64 `json:"cache_hits"`
+	CacheMisses int64 `json:"cache_misses"`
+
+	// CacheEvictions counts the values dropped from the cache because they
+	// expired or to make room for other values when the cache was full.
+	CacheEvictions int64 `json:"cache_evictions"`
+
+	// Conflicts is the number of conditional writes that did not happen
+	// because the tag was changed by someone else, plus the number of
+	// times Update failed with a retryable error.
+	Conflicts int64 `json:"conflicts"`
+}
+
+// CacheHitRate returns the ratio of reads served from the cache, or zero
+// if there were no reads through the cache.
+func (s Stats) CacheHitRate() float64 {
+	total := s.CacheHits + s.CacheMisses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.CacheHits) / float64(total)
+}
+
+// stats keeps the counters of an engine, both globally and per universe,
+// and the number of conflicts per key of every universe, along with the
+// number of operations per key if enabled using WithKeyStats.
+type stats struct {
+	mu        sync.Mutex
+	total     Stats
+	universes map[string]*Stats
+	keys      *keyCounter
+	conflicts *keyCounter
+}
+
+func newStats() *stats {
+	return &stats{
+		universes: make(map[string]*Stats),
+		conflicts: newKeyCounter(),
+	}
+}
+
+// update changes the counters for the given universe.
+func (s *stats) update(universe string, fn func(*Stats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.total)
+	u, ok := s.universes[universe]
+	if !ok {
+		u = &Stats{}
+		s.universes[universe] = u
+	}
+	fn(u)
+}
+
+// count increments the counter of a key of a universe in the given
+// counter, if any.
+func (s *stats) count(counter *keyCounter, universe, key string) {
+	if counter == nil || key == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counter.add(universe, key)
+}
+
+func (s *stats) op(universe, key string) {
+	s.update(universe, func(s *Stats) { s.Ops++ })
+	s.count(s.keys, universe, key)
+}
+
+func (s *stats) conflict(universe, key string) {
+	s.update(universe, func(s *Stats) { s.Conflicts++ })
+	s.count(s.conflicts, universe, key)
+}
+
+func (s *stats) error(universe string) {
+	s.update(universe, func(s *Stats) { s.Errors++ })
+}
+
+func (s *stats) cacheHit(universe string) {
+	s.update(universe, func(s *Stats) { s.CacheHits++ })
+}
+
+func (s *stats) cacheMiss(universe string) {
+	s.update(universe, func(s *Stats) { s.CacheMisses++ })
+}
+
+func (s *stats) cacheEviction(universe string) {
+	s.update(universe, func(s *Stats) { s.CacheEvictions++ })
+}
+
+// Stats returns the counters of every operation made by this engine.
+func (tags *Tags) Stats() Stats {
+	tags.stats.mu.Lock()
+	defer tags.stats.mu.Unlock()
+	return tags.stats.total
+}
+
+// UniverseStats returns the counters of the operations made by this engine
+// over the given universe.
+func (tags *Tags) UniverseStats(universe string) Stats {
+	tags.stats.mu.Lock()
+	defer tags.stats.mu.Unlock()
+	if u, ok := tags.stats.universes[universe]; ok {
+		return *u
+	}
+	return Stats{}
+}
+
+// KeyConflicts is the number of conflicts found while writing a key of a
+// universe.
+type KeyConflicts struct {
+	Universe  string
+	Key       string
+	Conflicts int64
+}
+
+// Conflicts returns the top keys with the most conflicts, so that hot keys
+// that are written concurrently too often can be found and redesigned.
+func (tags *Tags) Conflicts(top int) []KeyConflicts {
+	tags.stats.mu.Lock()
+	counts := tags.stats.conflicts.top(top)
+	tags.stats.mu.Unlock()
+	keys := make([]KeyConflicts, len(counts))
+	for i, count := range counts {
+		keys[i] = KeyConflicts{Universe: count.universe, Key: count.key, Conflicts: count.count}
+	}
+	return keys
+}
+
+// maxCountedKeys is the maximum number of keys whose counters are kept by
+// a keyCounter.
+const maxCountedKeys = 1000
+
+// A keyCounter counts the occurrences of the keys of every universe, but
+// only keeps the counters of up to maxCountedKeys keys. When it is full, a
+// new key replaces the key with the lowest counter and starts from its
+// count, which overestimates rare keys but never loses the frequent ones.
+type keyCounter struct {
+	counts map[universeKey]int64
+}
+
+type universeKey struct {
+	universe, key string
+}
+
+type keyCount struct {
+	universeKey
+	count int64
+}
+
+func newKeyCounter() *keyCounter {
+	return &keyCounter{counts: make(map[universeKey]int64)}
+}
+
+// add increments the counter of the given key.
+func (c *keyCounter) add(universe, key string) {
+	k := universeKey{universe, key}
+	if _, ok := c.counts[k]; !ok && len(c.counts) >= maxCountedKeys {
+		var lowest universeKey
+		lowestCount := int64(-1)
+		for other, count := range c.counts {
+			if lowestCount < 0 || count < lowestCount {
+				lowest, lowestCount = other, count
+			}
+		}
+		delete(c.counts, lowest)
+		c.counts[k] = lowestCount
+	}
+	c.counts[k]++
+}
+
+// top returns up to n keys with the highest counters, from the highest to
+// the lowest.
+func (c *keyCounter) top(n int) []keyCount {
+	if c == nil {
+		return nil
+	}
+	counts := make([]keyCount, 0, len(c.counts))
+	for k, count := range c.counts {
+		counts = append(counts, keyCount{k, count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].count != counts[j].count {
+			return counts[i].count > counts[j].count
+		}
+		if counts[i].universe != counts[j].universe {
+			return counts[i].universe < counts[j].universe
+		}
+		return counts[i].key < counts[j].key
+	})
+	if len(counts) > n {
+		counts = counts[:n]
+	}
+	return counts
+}
+
+// expvarStats is the representation of the statistics served by expvar.
+type expvarStats struct {
+	Stats
+	CacheHitRate float64                `json:"cache_hit_rate"`
+	Universes    map[string]expvarStats `json:"universes,omitempty"`
+}
+
+func (tags *Tags) expvarStats() any {
+	tags.stats.mu.Lock()
+	defer tags.stats.mu.Unlock()
+	result := expvarStats{
+		Stats:        tags.stats.total,
+		CacheHitRate: tags.stats.total.CacheHitRate(),
+		Universes:    make(map[string]expvarStats),
+	}
+	for universe, u := range tags.stats.universes {
+		result.Universes[universe] = expvarStats{Stats: *u, CacheHitRate: u.CacheHitRate()}
+	}
+	return result
+}
+
+var (
+	// published holds the engines published through expvar by name, since
+	// expvar does not allow to publish the same name twice.
+	publishedMu sync.Mutex
+	published   = make(map[string]*Tags)
+)
+
+// publishStats publishes the statistics of the engine using the given name.
+func publishStats(name string, tags *Tags) {
+	publishedMu.Lock()
+	defer publishedMu.Unlock()
+	if _, ok := published[name]; !ok {
+		expvar.Publish(name, expvar.Func(func() any {
+			publishedMu.Lock()
+			engine := published[name]
+			publishedMu.Unlock()
+			return engine.expvarStats()
+		}))
+	}
+	published[name] = tags
+}
@@ -0,0 +1,144 @@
+package tango
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestStatsCounters(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithCache(time.Minute, 0))
+
+	if err := tags.Tag("1234", "5678", "string").Set("hello"); err != nil {
+		t.Error(err)
+	}
+	var result int
+	for i := 0; i < 3; i++ {
+		// Decoding a string into an int fails, but reads are cached.
+		tags.Tag("1234", "5678", "string").Get(&result)
+	}
+	if err := tags.Tag("4321", "5678", "string").Delete(); err != nil {
+		t.Error(err)
+	}
+
+	stats := tags.Stats()
+	if stats.Ops != 5 || stats.Errors != 3 || stats.CacheHits != 2 || stats.CacheMisses != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if rate := stats.CacheHitRate(); rate < 0.66 || rate > 0.67 {
+		t.Errorf("Unexpected cache hit rate %f", rate)
+	}
+	if u := tags.UniverseStats("4321"); u.Ops != 1 || u.Errors != 0 {
+		t.Errorf("Unexpected universe stats %+v", u)
+	}
+	if u := tags.UniverseStats("0000"); u.Ops != 0 {
+		t.Errorf("Unexpected universe stats %+v", u)
+	}
+}
+
+func TestStatsExpvar(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	// Publishing twice with the same name replaces the engine.
+	NewTagsEngine(db, WithExpvar("tango_test"))
+	tags := NewTagsEngine(db, WithExpvar("tango_test"))
+	if err := tags.Tag("1234", "5678", "string").Set("hello"); err != nil {
+		t.Error(err)
+	}
+
+	var published struct {
+		Ops       int64 `json:"ops"`
+		Universes map[string]struct {
+			Ops int64 `json:"ops"`
+		} `json:"universes"`
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("tango_test").String()), &published); err != nil {
+		t.Fatal(err)
+	}
+	if published.Ops != 1 || published.Universes["1234"].Ops != 1 {
+		t.Errorf("Unexpected published stats %+v", published)
+	}
+}
+
+func TestStatsCacheEvictions(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithCache(time.Minute, 1))
+
+	var result string
+	tags.Tag("1234", "5678", "first").Get(&result)
+	tags.Tag("4321", "5678", "second").Get(&result)
+	tags.Tag("4321", "5678", "third").Get(&result)
+
+	if stats := tags.Stats(); stats.CacheEvictions != 2 {
+		t.Errorf("Expected 2 evictions, was %d", stats.CacheEvictions)
+	}
+	if u := tags.UniverseStats("1234"); u.CacheEvictions != 1 || u.CacheMisses != 1 {
+		t.Errorf("Unexpected universe stats %+v", u)
+	}
+}
+
+func TestStatsConflicts(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	hot := tags.Tag("1234", "5678", "hot")
+	if err := hot.Set("hello"); err != nil {
+		t.Error(err)
+	}
+	for i := 0; i < 2; i++ {
+		if removed, err := hot.DeleteIf("bye"); err != nil || removed {
+			t.Errorf("Expected hot not to be removed (%v, %v)", removed, err)
+		}
+	}
+	if _, err := tags.Tag("1234", "5678", "cold").DeleteIf("bye"); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("4321", "5678", "other").Set(1); err != nil {
+		t.Error(err)
+	}
+	if removed, err := tags.Tag("4321", "5678", "other").DeleteIf(2); err != nil || removed {
+		t.Errorf("Expected other not to be removed (%v, %v)", removed, err)
+	}
+
+	if stats := tags.Stats(); stats.Conflicts != 3 {
+		t.Errorf("Expected 3 conflicts, was %d", stats.Conflicts)
+	}
+	conflicts := tags.Conflicts(1)
+	if len(conflicts) != 1 || conflicts[0] != (KeyConflicts{Universe: "1234", Key: "hot", Conflicts: 2}) {
+		t.Errorf("Unexpected conflicts %+v", conflicts)
+	}
+}
+
+func TestStatsKeysBounded(t *testing.T) {
+	counter := newKeyCounter()
+	for i := 0; i < 5; i++ {
+		counter.add("1234", "hot")
+	}
+	for i := 0; i < 2*maxCountedKeys; i++ {
+		counter.add("1234", fmt.Sprintf("key%d", i))
+	}
+	if len(counter.counts) != maxCountedKeys {
+		t.Errorf("Expected %d counters, got %d", maxCountedKeys, len(counter.counts))
+	}
+	if top := counter.top(1); len(top) != 1 || top[0].key != "hot" || top[0].count != 5 {
+		t.Errorf("Expected the hot key to be kept, got %+v", top)
+	}
+}
@@ -0,0 +1,151 @@
+package tango
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// A TagStore provides the primitive operations over the tags of the store.
+// The engine itself is a TagStore, and every tag, tagbag and entity created
+// by the engine performs its basic operations (getting, setting, deleting
+// and listing tags) through the store configured using WithStore, so that
+// the engine can be decorated without replacing it.
+type TagStore interface {
+	// GetTag returns the JSON representation of the tag, and whether the
+	// tag is set.
+	GetTag(ctx context.Context, universe, entity, key string) (json.RawMessage, bool, error)
+
+	// SetTag changes the value of the tag.
+	SetTag(ctx context.Context, universe, entity, key string, value any) error
+
+	// DeleteTag removes the value of the tag.
+	DeleteTag(ctx context.Context, universe, entity, key string) error
+
+	// ListTags returns the keys of the tags set for the entity.
+	ListTags(ctx context.Context, universe, entity string) ([]string, error)
+}
+
+var _ TagStore = (*Tags)(nil)
+
+// checkUndecorated returns ErrDecoratedStore if the engine was configured
+// using WithStore. It guards the operations that run their own statements,
+// such as the atomic ones, which the decorators would not see.
+func (tags *Tags) checkUndecorated() error {
+	if len(tags.decorators) > 0 {
+		return ErrDecoratedStore
+	}
+	return nil
+}
+
+// GetTag returns the JSON representation of the tag from the database.
+func (tags *Tags) GetTag(ctx context.Context, universe, entity, key string) (json.RawMessage, bool, error) {
+	tag := tags.Tag(universe, entity, key)
+	ctx, done := tags.trace(ctx, "get", tag)
+	defer done()
+	ctx, cancel := tags.context(ctx)
+	defer cancel()
+	raw, exists, err := tag.read(ctx)
+	if err := tags.finish("get", tag, err); err != nil {
+		return nil, false, err
+	}
+	var result json.RawMessage
+	if exists {
+		result = json.RawMessage(raw)
+	}
+	if tags.shadow != nil {
+		tags.shadow.compareTag(ctx, universe, entity, key, result, exists)
+	}
+	return result, exists, nil
+}
+
+// read returns the value of the tag, either derived or fetched, following
+// its references if enabled.
+func (tag *Tag) read(ctx context.Context) (string, bool, error) {
+	if err := tag.tags.checkAddress(tag.universe, tag.entity, tag.key); err != nil {
+		return "", false, err
+	}
+	var raw string
+	var exists bool
+	var err error
+	if derivation, ok := tag.tags.derived[tag.key]; ok {
+		raw, exists, err = tag.fetchDerived(ctx, derivation)
+	} else {
+		raw, exists, err = tag.fetch(ctx)
+	}
+	if err == nil && tag.tags.maxRefDepth > 0 {
+		raw, exists, err = tag.resolve(ctx, raw, exists)
+	}
+	if err == nil && exists {
+		tag.touch(ctx)
+	}
+	return raw, exists, err
+}
+
+// SetTag validates and persists the value of the tag into the database.
+func (tags *Tags) SetTag(ctx context.Context, universe, entity, key string, value any) error {
+	tag := tags.Tag(universe, entity, key)
+	ctx, done := tags.trace(ctx, "set", tag)
+	defer done()
+	ctx, cancel := tags.context(ctx)
+	defer cancel()
+	err := tags.transaction(ctx, func(tx *txn) error {
+		return tag.setTx(tx, value)
+	})
+	return tags.finish("set", tag, err)
+}
+
+// DeleteTag removes the tag from the database.
+func (tags *Tags) DeleteTag(ctx context.Context, universe, entity, key string) error {
+	tag := tags.Tag(universe, entity, key)
+	ctx, done := tags.trace(ctx, "delete", tag)
+	defer done()
+	ctx, cancel := tags.context(ctx)
+	defer cancel()
+	err := tags.transaction(ctx, tag.deleteTx)
+	return tags.finish("delete", tag, err)
+}
+
+// ListTags returns the keys of the tags of the entity from the database.
+func (tags *Tags) ListTags(ctx context.Context, universe, entity string) ([]string, error) {
+	tag := &Tag{universe: universe, entity: entity}
+	ctx, done := tags.trace(ctx, "list", tag)
+	defer done()
+	ctx, cancel := tags.context(ctx)
+	defer cancel()
+	if err := tags.checkAddress(universe, entity); err != nil {
+		return nil, tags.finish("list", tag, err)
+	}
+	result, err := tags.listTags(ctx, universe, entity)
+	if err := tags.finish("list", tag, err); err != nil {
+		return nil, err
+	}
+	keys := tags.unscope(result)
+	if tags.shadow != nil {
+		tags.shadow.compareKeys(ctx, universe, entity, keys)
+	}
+	return keys, nil
+}
+
+func (tags *Tags) listTags(ctx context.Context, universe, entity string) ([]string, error) {
+	query := tags.sql(tagKeys)
+	traceStatement(ctx, query, universe, entity)
+	stmt, err := tags.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rs, err := stmt.QueryContext(ctx, universe, entity)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	result := []string{}
+	for rs.Next() {
+		var value string
+		rs.Scan(&value)
+		result = append(result, value)
+	}
+	return result, rs.Err()
+}
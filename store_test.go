@@ -0,0 +1,131 @@
+package tango
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+// countingStore is a decorator that counts the operations made through it.
+type countingStore struct {
+	TagStore
+	ops map[string]int
+}
+
+func (s *countingStore) GetTag(ctx context.Context, universe, entity, key string) (json.RawMessage, bool, error) {
+	s.ops["get"]++
+	return s.TagStore.GetTag(ctx, universe, entity, key)
+}
+
+func (s *countingStore) SetTag(ctx context.Context, universe, entity, key string, value any) error {
+	s.ops["set"]++
+	return s.TagStore.SetTag(ctx, universe, entity, key, value)
+}
+
+// readOnlyStore is a decorator that rejects every write.
+type readOnlyStore struct {
+	TagStore
+}
+
+var errReadOnly = errors.New("read only")
+
+func (readOnlyStore) SetTag(ctx context.Context, universe, entity, key string, value any) error {
+	return errReadOnly
+}
+
+func TestStoreDecorator(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	counter := &countingStore{ops: make(map[string]int)}
+	tags := NewTagsEngine(db, WithStore(func(store TagStore) TagStore {
+		counter.TagStore = store
+		return counter
+	}))
+
+	if err := tags.Tag("1234", "5678", "points").Set(10); err != nil {
+		t.Error(err)
+	}
+	if i, ok, err := tags.Entity("1234", "5678").Int("points"); err != nil || !ok || i != 10 {
+		t.Errorf("Expected points to be 10, was %d (%v, %v)", i, ok, err)
+	}
+	if list, err := tags.TagBag("1234", "5678").Tags(); err != nil || len(list) != 1 {
+		t.Errorf("Expected one tag, got %v (%v)", list, err)
+	}
+	if counter.ops["get"] != 1 || counter.ops["set"] != 1 {
+		t.Errorf("Expected operations to go through the decorator, got %v", counter.ops)
+	}
+}
+
+func TestStoreDecoratorOrder(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	counter := &countingStore{ops: make(map[string]int)}
+	tags := NewTagsEngine(db,
+		WithStore(func(store TagStore) TagStore {
+			return readOnlyStore{store}
+		}),
+		WithStore(func(store TagStore) TagStore {
+			counter.TagStore = store
+			return counter
+		}))
+
+	if err := tags.Tag("1234", "5678", "points").Set(10); !errors.Is(err, errReadOnly) {
+		t.Errorf("Expected write to be rejected, got %v", err)
+	}
+	if counter.ops["set"] != 1 {
+		t.Errorf("Expected outermost decorator to see the write, got %v", counter.ops)
+	}
+}
+
+func TestStoreDecoratorBypass(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithStore(func(store TagStore) TagStore {
+		return readOnlyStore{store}
+	}))
+	tag := tags.Tag("1234", "5678", "points")
+	bag := tags.TagBag("1234", "5678")
+
+	ops := map[string]func() error{
+		"Swap":           func() error { _, err := tag.Swap(10, nil); return err },
+		"GetOrSet":       func() error { var v int; _, err := tag.GetOrSet(&v, 10); return err },
+		"SetOnce":        func() error { return tag.SetOnce(10) },
+		"SetIfAbsent":    func() error { _, err := tag.SetIfAbsent(10); return err },
+		"DeleteExisting": func() error { _, err := tag.DeleteExisting(); return err },
+		"DeleteIf":       func() error { _, err := tag.DeleteIf(10); return err },
+		"Metadata":       func() error { _, _, err := tag.Metadata(); return err },
+		"Exists":         func() error { _, err := tag.Exists(); return err },
+		"Collection.Add": func() error { return tag.Collection(10, FIFO).Add(10) },
+		"DeletePrefix":   func() error { _, err := bag.DeletePrefix(""); return err },
+		"HasMany":        func() error { _, err := bag.HasMany([]string{"points"}); return err },
+		"TagsPage":       func() error { _, _, err := bag.TagsPage("", 10); return err },
+		"WriteJSON":      func() error { return bag.WriteJSON(io.Discard) },
+		"Update": func() error {
+			return tags.Update("1234", "5678", []string{"points"}, func(map[string]json.RawMessage) (map[string]any, error) {
+				return map[string]any{"points": 10}, nil
+			})
+		},
+	}
+	for name, op := range ops {
+		if err := op(); !errors.Is(err, ErrDecoratedStore) {
+			t.Errorf("Expected %s to fail with ErrDecoratedStore, got %v", name, err)
+		}
+	}
+	var points int
+	if exists, err := tag.Get(&points); err != nil || exists {
+		t.Errorf("Expected nothing to be written behind the decorator (%v, %v)", exists, err)
+	}
+}
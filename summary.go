@@ -0,0 +1,132 @@
+package tango
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+// An Aggregation is the function used to maintain a summary.
+type Aggregation int
+
+const (
+	// Sum adds up the numeric values of the key across the universe.
+	// Values that are not numbers do not contribute to the sum.
+	Sum Aggregation = iota
+
+	// Count counts the entities of the universe that have the key set.
+	Count
+)
+
+// A summary is an aggregation over the values of a key in a universe that
+// is maintained incrementally on every write.
+type summary struct {
+	name        string
+	aggregation Aggregation
+}
+
+var (
+	summaryUpdate = `
+	INSERT INTO {table}_summaries (universe, name, value) VALUES(?, ?, ?)
+	ON CONFLICT(universe, name) DO UPDATE SET value=value + excluded.value
+`
+	summaryReplace = `
+	INSERT INTO {table}_summaries (universe, name, value) VALUES(?, ?, ?)
+	ON CONFLICT(universe, name) DO UPDATE SET value=excluded.value
+`
+	summaryQuery = `SELECT value FROM {table}_summaries WHERE universe = ? AND name = ?`
+
+	summarySum = `
+	SELECT COALESCE(SUM(CASE WHEN json_type(value) IN ('integer', 'real') THEN CAST(value AS REAL) ELSE 0 END), 0)
+	FROM {table} WHERE universe = ? AND key = ?
+`
+	summaryCount = `SELECT COUNT(*) FROM {table} WHERE universe = ? AND key = ?`
+)
+
+// contribution returns how much a stored value contributes to the summary.
+func (s *summary) contribution(raw string, exists bool) float64 {
+	if !exists {
+		return 0
+	}
+	switch s.aggregation {
+	case Count:
+		return 1
+	case Sum:
+		var value float64
+		if err := json.Unmarshal([]byte(raw), &value); err == nil {
+			return value
+		}
+	}
+	return 0
+}
+
+// summarize updates the summaries that depend on the key of this tag, given
+// the value that is about to be stored, as part of the given transaction.
+func (tag *Tag) summarize(tx *txn, raw string, exists bool) error {
+	summaries := tag.tags.summaries[tag.key]
+	if len(summaries) == 0 {
+		return nil
+	}
+	old, existed, err := tag.fetchKey(tx.ctx, tx, tag.key)
+	if err != nil {
+		return err
+	}
+	return tag.summarizeChange(tx, old, existed, raw, exists)
+}
+
+// summarizeChange updates the summaries that depend on the key of this tag,
+// given the value that was stored before and the value that replaces it, as
+// part of the given transaction.
+func (tag *Tag) summarizeChange(tx *txn, old string, existed bool, raw string, exists bool) error {
+	for _, s := range tag.tags.summaries[tag.key] {
+		delta := s.contribution(raw, exists) - s.contribution(old, existed)
+		if delta == 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(tx.ctx, tag.tags.sql(summaryUpdate), tag.universe, s.name, delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Summary returns the current value of the summary with the given name for
+// the given universe. Summaries are registered using WithSummary. If the
+// summary was never updated for the universe, zero is returned.
+func (tags *Tags) Summary(universe, name string) (float64, error) {
+	ctx, cancel := tags.context(context.Background())
+	defer cancel()
+	var value float64
+	err := tags.db.QueryRowContext(ctx, tags.sql(summaryQuery), universe, name).Scan(&value)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return value, err
+}
+
+// RebuildSummaries computes again from scratch every summary for the given
+// universe. This is useful after registering a new summary, since data
+// written before will not be taken into account otherwise.
+func (tags *Tags) RebuildSummaries(ctx context.Context, universe string) error {
+	if err := tags.checkUnscoped(); err != nil {
+		return err
+	}
+	return tags.transaction(ctx, func(tx *txn) error {
+		for key, summaries := range tags.summaries {
+			for _, s := range summaries {
+				query := summarySum
+				if s.aggregation == Count {
+					query = summaryCount
+				}
+				var value float64
+				if err := tx.QueryRowContext(ctx, tags.sql(query), universe, key).Scan(&value); err != nil {
+					return err
+				}
+				if _, err := tx.ExecContext(ctx, tags.sql(summaryReplace), universe, s.name, value); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,69 @@
+package tango
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSummaryMaintained(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db,
+		WithSummary("total_points", "points", Sum),
+		WithSummary("players", "points", Count))
+
+	if err := tags.Tag("1234", "1", "points").Set(10); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "2", "points").Set(5); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "1", "points").Set(20); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "2", "points").Delete(); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("4321", "1", "points").Set(100); err != nil {
+		t.Error(err)
+	}
+
+	expected := map[string]float64{"total_points": 20, "players": 1}
+	for name, value := range expected {
+		result, err := tags.Summary("1234", name)
+		if err != nil {
+			t.Error(err)
+		}
+		if result != value {
+			t.Errorf("Expected summary %s to be %f, was %f", name, value, result)
+		}
+	}
+}
+
+func TestSummaryRebuild(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithSummary("total_points", "points", Sum))
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', '1', 'points', '10'),
+		('1234', '2', 'points', '2.5'),
+		('1234', '3', 'points', '"invalid"')`); err != nil {
+		t.Error(err)
+	}
+	if result, err := tags.Summary("1234", "total_points"); err != nil || result != 0 {
+		t.Errorf("Expected summary to be 0 before rebuilding, was %f (%v)", result, err)
+	}
+	if err := tags.RebuildSummaries(context.Background(), "1234"); err != nil {
+		t.Error(err)
+	}
+	if result, err := tags.Summary("1234", "total_points"); err != nil || result != 12.5 {
+		t.Errorf("Expected summary to be 12.5, was %f (%v)", result, err)
+	}
+}
@@ -39,6 +39,78 @@ a parameter should have the following schema:
 	CREATE INDEX IF NOT EXISTS tags_entities ON TAGS(universe, entity);
 	CREATE UNIQUE INDEX IF NOT EXISTS tags_id ON tags(universe, entity, key);
 
+Some features need additional tables. If summaries are used, the following
+table should exist too:
+
+	CREATE TABLE IF NOT EXISTS tags_summaries(
+		universe VARCHAR(64) NOT NULL,
+		name VARCHAR(64) NOT NULL,
+		value REAL NOT NULL DEFAULT 0,
+		PRIMARY KEY(universe, name)
+	);
+
+If tracking is enabled, the tags table should have the following columns too:
+
+	ALTER TABLE tags ADD COLUMN updated_at TIMESTAMP;
+	ALTER TABLE tags ADD COLUMN updated_by VARCHAR(64);
+
+If read tracking is enabled, the tags table should have the following column
+too:
+
+	ALTER TABLE tags ADD COLUMN read_at TIMESTAMP;
+
+If resumable bulk operations are used, the following table should exist too:
+
+	CREATE TABLE IF NOT EXISTS tags_checkpoints(
+		name VARCHAR(64) PRIMARY KEY,
+		cursor TEXT NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+
+If deletions are exported incrementally, the following table should exist
+too:
+
+	CREATE TABLE IF NOT EXISTS tags_tombstones(
+		universe VARCHAR(64) NOT NULL,
+		entity VARCHAR(64) NOT NULL,
+		key VARCHAR(64) NOT NULL,
+		deleted_at TIMESTAMP NOT NULL,
+		PRIMARY KEY(universe, entity, key)
+	);
+
+If locks are used to coordinate processes, the following table should exist
+too:
+
+	CREATE TABLE IF NOT EXISTS tags_locks(
+		name VARCHAR(64) PRIMARY KEY,
+		owner VARCHAR(64) NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	);
+
+# Identifiers
+
+Universes, entities and keys can be any string. However, to be able to move
+the data into stores that flatten the address of a tag into a single key,
+identifiers should only use printable ASCII characters other than the
+percent sign and the slash. Colons are allowed, and are used to namespace
+identifiers, such as "telegram:1234". EscapeID encodes arbitrary
+identifiers into this portable form, and StorageKey flattens the address of
+a tag using slashes. Engines configured with WithPortableIDs reject writes
+using identifiers that are not portable, and engines configured with
+WithStrictIDs reject operations using empty or too long identifiers.
+
+# Compatibility
+
+The API only grows by addition. The signatures of NewTagsEngine and of the
+methods of Tag are kept, and new behaviour is enabled by passing options to
+NewTagsEngine or OpOption values to the methods that accept them, so that
+applications can upgrade the package without changing their code. Context
+aware operations are provided by the TagStore interface implemented by Tags,
+which accepts operation options through ContextWithOptions. Methods that are
+replaced are marked as deprecated and kept until the next major version,
+which would live in a separate module path so that both versions can be
+used by the same program during a migration.
+
 # Open Source Policy
 
 This package has been made open source in the hope that it is useful for
@@ -54,148 +126,601 @@ are free to write their own integrations.
 package tango
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
 )
 
 // A Tag is a piece of metadata attached to an entity. The Tag interface
 // provides methods to extract or modify the value associated with a specific
 // tag in the entity dictionary.
 type Tag struct {
-	db       *sql.DB
+	tags     *Tags
 	universe string
 	entity   string
 	key      string
+
+	// name is the key that was used to request this tag, which is not the
+	// same as key when the tag was requested using an alias.
+	name string
 }
 
 var (
 	tagUpsert = `
-	INSERT INTO tags (universe, entity, key, value) VALUES(?, ?, ?, ?)
+	INSERT INTO {table} (universe, entity, key, value) VALUES(?, ?, ?, ?)
 	ON CONFLICT(universe, entity, key) DO UPDATE SET value=excluded.value
 `
-	tagQuery  = `SELECT value FROM tags WHERE universe = ? AND entity = ? AND key = ?`
-	tagDelete = `DELETE FROM tags WHERE universe = ? AND entity = ? AND key = ?`
+	tagQuery  = `SELECT value FROM {table} WHERE universe = ? AND entity = ? AND key = ?`
+	tagDelete = `DELETE FROM {table} WHERE universe = ? AND entity = ? AND key = ?`
 
-	tagKeys = `SELECT key FROM tags WHERE universe = ? AND entity = ?`
+	tagKeys = `SELECT key FROM {table} WHERE universe = ? AND entity = ?`
 )
 
 // Get the current value of the tag from the persistence. If the tag
 // database has a tag for this, it will put the value into the out
 // variable and return true. Otherwise, this method returns false.
-func (tag *Tag) Get(out any) (bool, error) {
-	// Prepare the statement and fetch the results.
-	stmt, err := tag.db.Prepare(tagQuery)
+func (tag *Tag) Get(out any, opts ...OpOption) (bool, error) {
+	return tag.GetContext(context.Background(), out, opts...)
+}
+
+// GetContext works like Get, but the query runs under the given context, so
+// that its deadline and cancellation bound the call to the database.
+func (tag *Tag) GetContext(ctx context.Context, out any, opts ...OpOption) (bool, error) {
+	tag.warnDeprecated("get")
+	ctx = withOpOptions(ctx, opts)
+	raw, exists, err := tag.tags.store.GetTag(ctx, tag.universe, tag.entity, tag.name)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	// Convert the raw string into the proper datatype.
+	if err := tag.decode(raw, out); err != nil {
+		return false, tag.tags.failed("get", tag, err)
+	}
+	return true, nil
+}
+
+// GetRaw returns the JSON representation of the value of the tag, without
+// decoding it, and whether the tag is set. The value is read like Get reads
+// it, so it may come from a legacy alias, a loader, a default value, a
+// derivation or a reference rather than from the row of the tag. It is
+// useful to defer decoding, to inspect the type of the value before
+// decoding it or to forward the value as is.
+func (tag *Tag) GetRaw(opts ...OpOption) (json.RawMessage, bool, error) {
+	return tag.GetRawContext(context.Background(), opts...)
+}
+
+// GetRawContext works like GetRaw, but the query runs under the given
+// context, so that its deadline and cancellation bound the call to the
+// database.
+func (tag *Tag) GetRawContext(ctx context.Context, opts ...OpOption) (json.RawMessage, bool, error) {
+	tag.warnDeprecated("get")
+	ctx = withOpOptions(ctx, opts)
+	return tag.tags.store.GetTag(ctx, tag.universe, tag.entity, tag.name)
+}
+
+// GetOrDefault puts the value of the tag into the out variable, like Get,
+// but if the tag is not set, it puts the given value into the out variable
+// instead, without writing it. It returns whether the tag was set.
+func (tag *Tag) GetOrDefault(out any, def any, opts ...OpOption) (bool, error) {
+	exists, err := tag.Get(out, opts...)
+	if err != nil || exists {
+		return exists, err
+	}
+	raw, err := tag.tags.codec.Marshal(def)
 	if err != nil {
 		return false, err
 	}
+	if err := tag.decode(raw, out); err != nil {
+		return false, tag.tags.failed("get", tag, err)
+	}
+	return false, nil
+}
+
+// Exists returns whether the tag is set, without decoding its value. Legacy
+// aliases and derived keys are taken into account, and the cache and the
+// bloom filter of the engine are used, but loaders and default values are
+// not, since they do not make the tag set.
+func (tag *Tag) Exists() (bool, error) {
+	tag.warnDeprecated("get")
+	if err := tag.tags.checkUndecorated(); err != nil {
+		return false, tag.tags.failed("get", tag, err)
+	}
+	ctx, done := tag.tags.trace(context.Background(), "get", tag)
+	defer done()
+	ctx, cancel := tag.tags.context(ctx)
+	defer cancel()
+	exists, err := tag.exists(ctx)
+	return exists, tag.tags.finish("get", tag, err)
+}
+
+// exists returns whether the tag is set, following the same path as read
+// but without using the loaders and the default values.
+func (tag *Tag) exists(ctx context.Context) (bool, error) {
+	if err := tag.tags.checkAddress(tag.universe, tag.entity, tag.key); err != nil {
+		return false, err
+	}
+	if derivation, ok := tag.tags.derived[tag.key]; ok {
+		_, exists, err := tag.fetchDerived(ctx, derivation)
+		return exists, err
+	}
+	// Cached values may come from the default value of the key, which
+	// does not make the tag set.
+	_, hasDefault := tag.tags.defaults[tag.key]
+	if _, exists, ok := tag.tags.cache.get(tag.universe, tag.entity, tag.key); ok && !opConfigFrom(ctx).skipCache && (!exists || !hasDefault) {
+		tag.tags.stats.cacheHit(tag.universe)
+		return exists, nil
+	}
+	for _, key := range append([]string{tag.key}, tag.tags.legacy[tag.key]...) {
+		_, exists, err := tag.lookup(ctx, key)
+		if err != nil || exists {
+			return exists, err
+		}
+	}
+	return false, nil
+}
+
+// fetch returns the JSON representation of whatever is stored in the
+// database for this tag, and whether there is something stored at all.
+// If the tag is missing but there is data stored under a legacy alias of
+// the key, the legacy data will be returned instead. If there is no data
+// at all but there is a loader registered for the key, the loader will be
+// used to compute and persist the value. Otherwise, the default value
+// declared in the manifest of the engine is used. Results are cached if the engine
+// was configured with a cache, unless the operation skips the cache.
+func (tag *Tag) fetch(ctx context.Context) (string, bool, error) {
+	cfg := opConfigFrom(ctx)
+	if raw, exists, ok := tag.tags.cache.get(tag.universe, tag.entity, tag.key); ok && !cfg.skipCache {
+		tag.tags.stats.cacheHit(tag.universe)
+		return raw, exists, nil
+	} else if tag.tags.cache != nil {
+		tag.tags.stats.cacheMiss(tag.universe)
+	}
+	raw, exists, err := tag.lookup(ctx, tag.key)
+	if err != nil {
+		return "", false, err
+	}
+	if !exists {
+		raw, exists, err = tag.fetchFallback(ctx)
+		if err != nil {
+			return "", false, err
+		}
+	}
+	tag.tags.cache.put(tag.universe, tag.entity, tag.key, raw, exists, cfg.ttl)
+	return raw, exists, nil
+}
+
+// fetchFallback looks for data for a tag that is not set, either under the
+// legacy aliases of the key, using the loader registered for the key or
+// using the default value of the key.
+func (tag *Tag) fetchFallback(ctx context.Context) (string, bool, error) {
+	for _, legacy := range tag.tags.legacy[tag.key] {
+		raw, exists, err := tag.lookup(ctx, legacy)
+		if err != nil {
+			return "", false, err
+		}
+		if !exists {
+			continue
+		}
+		// Data that cannot be written under the new key, because the
+		// engine is read-only or because it violates the constraints of
+		// the key, is served but left under the old key.
+		if tag.tags.rewriteAliases && tag.checkWritable() == nil && tag.constrain([]byte(raw)) == nil {
+			if err := tag.store(ctx, raw); err != nil {
+				return "", false, err
+			}
+		}
+		return raw, true, nil
+	}
+	if loader, ok := tag.tags.loaders[tag.key]; ok {
+		return tag.load(ctx, loader)
+	}
+	if raw, ok := tag.tags.defaults[tag.key]; ok {
+		return raw, true, nil
+	}
+	return "", false, nil
+}
+
+// lookup returns the JSON representation stored in the database for the
+// given key of the entity, unless the bloom filter of the engine tells
+// that the key is definitely not set.
+func (tag *Tag) lookup(ctx context.Context, key string) (string, bool, error) {
+	if !tag.tags.bloom.mayContain(ctx, tag.tags, tag.universe, tag.entity, key) {
+		return "", false, nil
+	}
+	return tag.fetchKey(ctx, tag.tags.db, key)
+}
+
+// fetchKey returns the JSON representation stored in the database for the
+// given key of the entity this tag belongs to.
+func (tag *Tag) fetchKey(ctx context.Context, q querier, key string) (string, bool, error) {
+	// Prepare the statement and fetch the results.
+	query := tag.tags.sql(tagQuery)
+	traceStatement(ctx, query, tag.universe, tag.entity, key)
+	stmt, err := q.PrepareContext(ctx, query)
+	if err != nil {
+		return "", false, err
+	}
 	defer stmt.Close()
-	rs, err := stmt.Query(tag.universe, tag.entity, tag.key)
+	rs, err := stmt.QueryContext(ctx, tag.universe, tag.entity, key)
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
 	defer rs.Close()
 
 	// if Next() returns true, we have a result. Otherwise, we just haven't.
 	if !rs.Next() {
-		return false, nil
+		return "", false, rs.Err()
 	}
-
-	// Get the JSON representation of whatever is stored in the database.
 	var raw string
 	if err := rs.Scan(&raw); err != nil {
-		return false, err
-	}
-
-	// Convert the raw string into the proper datatype.
-	if err := json.Unmarshal([]byte(raw), out); err != nil {
-		// IOError
-		return false, err
+		return "", false, err
 	}
-	return true, nil
+	return raw, true, nil
 }
 
 // Set the value of the tag in the persistence engine. After calling
 // this method, the value will be persisted into the value of the tag.
-// Any other error will be reported.
-func (tag *Tag) Set(value any) error {
-	raw, err := json.Marshal(value)
+// Any other error will be reported. If the engine was configured with
+// WithNullAsDelete, setting the tag to nil will delete it instead. Keys
+// configured using WithImmutableKeys fail with ErrImmutable if set.
+func (tag *Tag) Set(value any, opts ...OpOption) error {
+	return tag.SetContext(context.Background(), value, opts...)
+}
+
+// SetContext works like Set, but the statements run under the given
+// context, so that its deadline and cancellation bound the call to the
+// database.
+func (tag *Tag) SetContext(ctx context.Context, value any, opts ...OpOption) error {
+	tag.warnDeprecated("set")
+	ctx = withOpOptions(ctx, opts)
+	return tag.tags.store.SetTag(ctx, tag.universe, tag.entity, tag.name, value)
+}
+
+// setTx validates and persists the value of the tag as part of the given
+// transaction.
+func (tag *Tag) setTx(tx *txn, value any) error {
+	rawJson, err := tag.encodeTx(tx, value)
 	if err != nil {
 		return err
 	}
-	rawJson := string(raw)
-	tx, err := tag.db.Begin()
-	if err != nil {
+	if rawJson == "null" && tag.tags.nullAsDelete {
+		return tag.deleteTx(tx)
+	}
+	if err := tag.constrain([]byte(rawJson)); err != nil {
 		return err
 	}
-	defer tx.Rollback()
-	stmt, err := tx.Prepare(tagUpsert)
+	return tag.storeTx(tx, rawJson)
+}
+
+// encodeTx checks that the tag can be written, validates the value and
+// returns its JSON representation, as part of the given transaction.
+func (tag *Tag) encodeTx(tx *txn, value any) (string, error) {
+	if err := tag.tags.checkIDs(tag.universe, tag.entity, tag.key); err != nil {
+		return "", err
+	}
+	if err := tag.checkWritable(); err != nil {
+		return "", err
+	}
+	if tag.tags.immutable[tag.key] {
+		if err := tag.checkUnset(tx); err != nil {
+			return "", err
+		}
+	}
+	value, err := tag.validate(tx, value)
 	if err != nil {
+		return "", err
+	}
+	raw, err := tag.tags.codec.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// store persists the given JSON representation as the value of the tag.
+func (tag *Tag) store(ctx context.Context, rawJson string) error {
+	return tag.tags.transaction(ctx, func(tx *txn) error {
+		return tag.storeTx(tx, rawJson)
+	})
+}
+
+// storeTx persists the given JSON representation as the value of the tag
+// as part of the given transaction. Data stored under legacy aliases of the
+// key is removed, since it has been superseded by the new value.
+func (tag *Tag) storeTx(tx *txn, rawJson string) error {
+	if err := tag.checkQuota(tx); err != nil {
 		return err
 	}
-	defer stmt.Close()
-	if _, err := stmt.Exec(tag.universe, tag.entity, tag.key, rawJson); err != nil {
+	if err := tag.summarize(tx, rawJson, true); err != nil {
 		return err
 	}
-	tx.Commit()
+	if err := tag.upsert(tx, rawJson); err != nil {
+		return err
+	}
+	for _, legacy := range tag.tags.legacy[tag.key] {
+		if _, err := tx.ExecContext(tx.ctx, tag.tags.sql(tagDelete), tag.universe, tag.entity, legacy); err != nil {
+			return err
+		}
+	}
+	tx.written(tag, rawJson, true)
 	return nil
 }
 
 // Delete the value of the tag, if such is set. This method should
 // fail silently if the persistence lacks the key already.
-func (tag *Tag) Delete() error {
-	tx, err := tag.db.Begin()
-	if err != nil {
-		return err
+func (tag *Tag) Delete(opts ...OpOption) error {
+	return tag.DeleteContext(context.Background(), opts...)
+}
+
+// DeleteContext works like Delete, but the statements run under the given
+// context, so that its deadline and cancellation bound the call to the
+// database.
+func (tag *Tag) DeleteContext(ctx context.Context, opts ...OpOption) error {
+	tag.warnDeprecated("delete")
+	ctx = withOpOptions(ctx, opts)
+	return tag.tags.store.DeleteTag(ctx, tag.universe, tag.entity, tag.name)
+}
+
+// deleteTx removes the value of the tag, and any data stored under legacy
+// aliases of the key, as part of the given transaction.
+func (tag *Tag) deleteTx(tx *txn) error {
+	_, err := tag.removeTx(tx)
+	return err
+}
+
+// removeTx works like deleteTx, but also reports whether something was
+// actually removed.
+func (tag *Tag) removeTx(tx *txn) (bool, error) {
+	if err := tag.tags.checkAddress(tag.universe, tag.entity, tag.key); err != nil {
+		return false, err
 	}
-	defer tx.Rollback()
-	stmt, err := tx.Prepare(tagDelete)
+	if err := tag.checkWritable(); err != nil {
+		return false, err
+	}
+	if err := tag.summarize(tx, "", false); err != nil {
+		return false, err
+	}
+	query := tag.tags.sql(tagDelete)
+	stmt, err := tx.PrepareContext(tx.ctx, query)
 	if err != nil {
-		return err
+		return false, err
 	}
-	if _, err := stmt.Exec(tag.universe, tag.entity, tag.key); err != nil {
-		return err
+	defer stmt.Close()
+	var removed int64
+	for _, key := range append([]string{tag.key}, tag.tags.legacy[tag.key]...) {
+		traceStatement(tx.ctx, query, tag.universe, tag.entity, key)
+		result, err := stmt.ExecContext(tx.ctx, tag.universe, tag.entity, key)
+		if err != nil {
+			return false, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+		removed += affected
 	}
-	tx.Commit()
-	return nil
+	if removed > 0 {
+		if err := tag.tombstone(tx); err != nil {
+			return false, err
+		}
+		tx.written(tag, "", false)
+	}
+	return removed > 0, nil
 }
 
 // A TagBag is a collection of tags attached to an entity.
 type TagBag struct {
-	db       *sql.DB
+	tags     *Tags
 	universe string
 	entity   string
 }
 
 // Tag returns a particular tag from the entity given the name of the tag.
+// If the name of the tag is an alias, the tag it points to is returned.
 func (bag *TagBag) Tag(key string) *Tag {
-	return &Tag{db: bag.db, universe: bag.universe, entity: bag.entity, key: key}
+	name := key
+	key = bag.tags.keyPrefix + key
+	if alias, ok := bag.tags.aliases[key]; ok {
+		key = alias
+	}
+	return &Tag{tags: bag.tags, universe: bag.universe, entity: bag.entity, key: key, name: name}
 }
 
 // Tags returns a list of all the tags in the current tagbag.
 func (bag *TagBag) Tags() ([]string, error) {
-	stmt, err := bag.db.Prepare(tagKeys)
-	if err != nil {
-		return nil, err
+	return bag.tags.store.ListTags(context.Background(), bag.universe, bag.entity)
+}
+
+// Tags is the engine that manages the tags persisted into a database.
+type Tags struct {
+	db           *sql.DB
+	store        TagStore
+	decorators   []func(TagStore) TagStore
+	shadow       *shadowStore
+	table        string
+	keyPrefix    string
+	readOnly     bool
+	quotas       []scopeQuota
+	timeout      time.Duration
+	codec        Codec
+	logger       *log.Logger
+	cache        *cache
+	bloom        *bloomFilters
+	stats        *stats
+	watchers     *watchers
+	slowOp       *slowOp
+	classifier   func(error) bool
+	nullAsDelete bool
+	tracking     bool
+	tombstones   bool
+	portableIDs  bool
+	strictIDs    bool
+	maxIDLength  int
+
+	readSampleRate float64
+
+	universeFilter func(string) bool
+
+	// aliases maps old key names into new key names, and legacy maps new
+	// key names into the list of old key names that point to them.
+	aliases        map[string]string
+	legacy         map[string][]string
+	rewriteAliases bool
+
+	loaders     map[string]Loader
+	defaults    map[string]string
+	manifest    *Manifest
+	maxRefDepth int
+
+	startupSample int
+	startupReport func([]Violation)
+
+	// derived maps keys into the derivations that compute them. The
+	// dependencies map keys into the keys they depend on, and
+	// dependentKeys maps keys into the keys that depend on them.
+	derived       map[string]Derivation
+	dependencies  map[string][]string
+	dependentKeys map[string][]string
+
+	immutable   map[string]bool
+	constraints map[string][]constraint
+	sensitive   map[string]bool
+	summaries   map[string][]*summary
+
+	validators         []Validator
+	universeValidators map[string][]Validator
+
+	deprecated      map[string]bool
+	deprecationHook func(Deprecation)
+}
+
+// A querier is able to run statements, either in a database or as part of
+// a transaction.
+type querier interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// A txn is a transaction in progress. It carries the context of the
+// operation and the tags written as part of the transaction, which should
+// be updated in the cache once the transaction is committed, along with
+// other callbacks to run once it is committed. Every write made as part of
+// the transaction is recorded at the same time, taken when it started.
+type txn struct {
+	*sql.Tx
+	ctx      context.Context
+	now      time.Time
+	writes   []txnWrite
+	onCommit []func()
+}
+
+type txnWrite struct {
+	tag    *Tag
+	raw    string
+	exists bool
+	at     time.Time
+}
+
+// written records that the tag was written as part of the transaction.
+func (tx *txn) written(tag *Tag, raw string, exists bool) {
+	if exists {
+		tag.tags.bloom.add(tag.universe, tag.entity, tag.key)
 	}
-	defer stmt.Close()
+	tx.writes = append(tx.writes, txnWrite{tag: tag, raw: raw, exists: exists, at: tx.now})
+}
 
-	rs, err := stmt.Query(bag.universe, bag.entity)
+// committed registers a callback to run once the transaction is committed.
+func (tx *txn) committed(fn func()) {
+	tx.onCommit = append(tx.onCommit, fn)
+}
+
+// transaction runs the given function as part of a transaction, which is
+// committed if the function succeeds and rolled back otherwise.
+func (tags *Tags) transaction(ctx context.Context, fn func(tx *txn) error) error {
+	sqlTx, err := tags.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rs.Close()
+	tx := &txn{Tx: sqlTx, ctx: ctx, now: time.Now().UTC()}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	// Written tags are evicted from the cache, unless the operation asked
+	// to cache them for a specific amount of time. The keys that depend on
+	// them are always evicted.
+	ttl := opConfigFrom(ctx).ttl
+	for _, w := range tx.writes {
+		if ttl > 0 {
+			tags.cache.put(w.tag.universe, w.tag.entity, w.tag.key, w.raw, w.exists, ttl)
+		} else {
+			tags.cache.evict(w.tag.universe, w.tag.entity, w.tag.key)
+		}
+		for _, dependent := range tags.dependents(w.tag.key) {
+			tags.cache.evict(w.tag.universe, w.tag.entity, dependent)
+		}
+	}
+	tags.watchers.notify(tx.writes)
+	for _, fn := range tx.onCommit {
+		fn()
+	}
+	return nil
+}
 
-	result := []string{}
-	for rs.Next() {
-		var value string
-		rs.Scan(&value)
-		result = append(result, value)
+// context returns the context in which an operation should run, applying
+// the timeout given to the operation or, if none, the timeout configured for
+// the engine, if any.
+func (tags *Tags) context(parent context.Context) (context.Context, context.CancelFunc) {
+	if timeout := opConfigFrom(parent).timeout; timeout > 0 {
+		return context.WithTimeout(parent, timeout)
+	}
+	if tags.timeout > 0 {
+		return context.WithTimeout(parent, tags.timeout)
 	}
-	return result, nil
+	return context.WithCancel(parent)
 }
 
-type Tags struct {
-	db *sql.DB
+// sql returns the given query after replacing the table placeholders with
+// the name of the table configured for the engine.
+func (tags *Tags) sql(query string) string {
+	return strings.ReplaceAll(query, "{table}", tags.table)
+}
+
+// finish accounts an operation over a tag that has just finished in the
+// statistics of the engine, reporting the error if it failed. It returns
+// the error wrapped into an Error, so that it can be used when returning.
+func (tags *Tags) finish(op string, tag *Tag, err error) error {
+	tags.stats.op(tag.universe, tag.key)
+	return tags.failed(op, tag, err)
+}
+
+// failed reports an error that happened while running an operation over a
+// tag through the logger configured for the engine, and accounts it in the
+// statistics of the engine. It returns the error wrapped into an Error, so
+// that it can be used when returning.
+func (tags *Tags) failed(op string, tag *Tag, err error) error {
+	if err == nil {
+		return nil
+	}
+	tags.stats.error(tag.universe)
+	if errors.Is(err, ErrConflict) {
+		tags.stats.conflict(tag.universe, tag.key)
+	}
+	err = tags.wrapError(op, tag, err)
+	if tags.logger != nil {
+		tags.logger.Print(err)
+	}
+	return err
 }
 
 // TagBag returns the proper tagbag collection for a given entity part of an
@@ -203,7 +728,7 @@ type Tags struct {
 // and entity, calling this method reusing one of the parameters but keeping
 // the other one constant, will yield different dictionaries.
 func (tags *Tags) TagBag(universe, entity string) *TagBag {
-	return &TagBag{db: tags.db, universe: universe, entity: entity}
+	return &TagBag{tags: tags, universe: universe, entity: entity}
 }
 
 // Tag is a shortcut to get a specific tag for a specific compound key made
@@ -212,9 +737,34 @@ func (tags *Tags) Tag(universe, entity, key string) *Tag {
 	return tags.TagBag(universe, entity).Tag(key)
 }
 
+// Entity returns a handle to the given entity part of an universe, which
+// provides typed accessors to the tags of the entity.
+func (tags *Tags) Entity(universe, entity string) *Entity {
+	return &Entity{tags: tags, universe: universe, entity: entity}
+}
+
+// ForUniverse returns a view of this engine that is bound to the given
+// universe, so that entities and tags can be addressed without having to
+// provide the universe ID again.
+func (tags *Tags) ForUniverse(universe string) *Universe {
+	return &Universe{tags: tags, universe: universe}
+}
+
 // NewTagsEngine returns a valid tags manager that persist into the given
 // database. Note that while the function accepts a generic sql.DB object,
-// it requires a migration that
-func NewTagsEngine(db *sql.DB) *Tags {
-	return &Tags{db}
+// it requires a migration that creates the schema described in the package
+// documentation. The behaviour of the engine can be tuned with options.
+func NewTagsEngine(db *sql.DB, opts ...Option) *Tags {
+	tags := &Tags{db: db, table: "tags", codec: JSONCodec{}, stats: newStats(), watchers: newWatchers()}
+	for _, opt := range opts {
+		opt(tags)
+	}
+	tags.store = tags
+	for _, decorator := range tags.decorators {
+		tags.store = decorator(tags.store)
+	}
+	if tags.startupReport != nil && tags.manifest != nil {
+		go tags.validateOnStart()
+	}
+	return tags
 }
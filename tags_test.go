@@ -1,7 +1,9 @@
 package tango
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -21,10 +23,36 @@ func prepareTagEngine() (*sql.DB, *Tags, error) {
 		universe VARCHAR(64) NOT NULL,
 		entity VARCHAR(64) NOT NULL,
 		key VARCHAR(64) NOT NULL,
-		value TEXT
+		value TEXT,
+		updated_at TIMESTAMP,
+		updated_by VARCHAR(64),
+		read_at TIMESTAMP
 	);
 	CREATE INDEX IF NOT EXISTS tags_entities ON TAGS(universe, entity);
-	CREATE UNIQUE INDEX IF NOT EXISTS tags_id ON tags(universe, entity, key);`
+	CREATE UNIQUE INDEX IF NOT EXISTS tags_id ON tags(universe, entity, key);
+	CREATE TABLE IF NOT EXISTS tags_summaries(
+		universe VARCHAR(64) NOT NULL,
+		name VARCHAR(64) NOT NULL,
+		value REAL NOT NULL DEFAULT 0,
+		PRIMARY KEY(universe, name)
+	);
+	CREATE TABLE IF NOT EXISTS tags_checkpoints(
+		name VARCHAR(64) PRIMARY KEY,
+		cursor TEXT NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS tags_tombstones(
+		universe VARCHAR(64) NOT NULL,
+		entity VARCHAR(64) NOT NULL,
+		key VARCHAR(64) NOT NULL,
+		deleted_at TIMESTAMP NOT NULL,
+		PRIMARY KEY(universe, entity, key)
+	);
+	CREATE TABLE IF NOT EXISTS tags_locks(
+		name VARCHAR(64) PRIMARY KEY,
+		owner VARCHAR(64) NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	);`
 	if _, err := db.Exec(schema); err != nil {
 		db.Close()
 		return nil, nil, err
@@ -967,3 +995,105 @@ func TestTagsDeleteNonExisting(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestTagsContext(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	tag := tags.Tag("1234", "5678", "points")
+
+	if err := tag.SetContext(ctx, 33); err != nil {
+		t.Error(err)
+	}
+	var points int
+	if exists, err := tag.GetContext(ctx, &points); err != nil || !exists || points != 33 {
+		t.Errorf("Expected points to be 33, was %d (%v, %v)", points, exists, err)
+	}
+	if err := tag.DeleteContext(ctx); err != nil {
+		t.Error(err)
+	}
+	if exists, err := tag.GetContext(ctx, &points); err != nil || exists {
+		t.Errorf("Expected points to be deleted (%v, %v)", exists, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := tag.SetContext(cancelled, 33); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled context to fail the write, got %v", err)
+	}
+	if _, err := tag.GetContext(cancelled, &points); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled context to fail the read, got %v", err)
+	}
+}
+
+func TestTagsExists(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithAlias("lang", "language"), WithManifest(NewManifest(
+		Setting{Key: "points", Kind: KindNumber, Default: 0},
+	)))
+
+	if exists, err := tags.Tag("1234", "5678", "points").Exists(); err != nil || exists {
+		t.Errorf("Expected a default value not to exist (%v, %v)", exists, err)
+	}
+	if err := tags.Tag("1234", "5678", "points").Set(10); err != nil {
+		t.Error(err)
+	}
+	if exists, err := tags.Tag("1234", "5678", "points").Exists(); err != nil || !exists {
+		t.Errorf("Expected points to exist (%v, %v)", exists, err)
+	}
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES('1234', '5678', 'lang', '"es"')`); err != nil {
+		t.Error(err)
+	}
+	if exists, err := tags.Tag("1234", "5678", "language").Exists(); err != nil || !exists {
+		t.Errorf("Expected legacy data to exist (%v, %v)", exists, err)
+	}
+}
+
+func TestTagsExistsChecked(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithUniverses("1234"), WithDerived("rank", func(e *Entity) (any, bool, error) {
+		return "gold", true, nil
+	}))
+
+	if _, err := tags.Tag("4321", "5678", "points").Exists(); !errors.Is(err, ErrUnknownUniverse) {
+		t.Errorf("Expected ErrUnknownUniverse, got %v", err)
+	}
+	if exists, err := tags.Tag("1234", "5678", "rank").Exists(); err != nil || !exists {
+		t.Errorf("Expected a derived key to exist (%v, %v)", exists, err)
+	}
+}
+
+func TestTagsGetRaw(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tag := tags.Tag("1234", "5678", "profile")
+
+	if raw, exists, err := tag.GetRaw(); err != nil || exists || raw != nil {
+		t.Errorf("Expected a missing tag, got %s (%v, %v)", raw, exists, err)
+	}
+	if err := tag.Set(map[string]any{"name": "makigas"}); err != nil {
+		t.Error(err)
+	}
+	if raw, exists, err := tag.GetRaw(); err != nil || !exists || string(raw) != `{"name":"makigas"}` {
+		t.Errorf("Expected the stored JSON, got %s (%v, %v)", raw, exists, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := tag.GetRawContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the cancelled context to be honoured, got %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+// Package tangotest provides a conformance suite for implementations of
+// tango.TagStore, so that alternative backends and decorators can check
+// that they behave like the engine.
+package tangotest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"gopkg.makigas.es/tango"
+)
+
+// A Factory returns an empty store to run a test against. It is called
+// once per test, and it can use t.Cleanup to release the store.
+type Factory func(t *testing.T) tango.TagStore
+
+// RunStoreTests runs the conformance suite against the stores returned by
+// the factory, as subtests of t.
+func RunStoreTests(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store tango.TagStore)
+	}{
+		{"GetMissing", testGetMissing},
+		{"SetAndGet", testSetAndGet},
+		{"Upsert", testUpsert},
+		{"Null", testNull},
+		{"Delete", testDelete},
+		{"List", testList},
+		{"Isolation", testIsolation},
+		{"Concurrency", testConcurrency},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			test.fn(t, factory(t))
+		})
+	}
+}
+
+// mustSet sets a tag, failing the test if it cannot be set.
+func mustSet(t *testing.T, store tango.TagStore, universe, entity, key string, value any) {
+	t.Helper()
+	if err := store.SetTag(context.Background(), universe, entity, key, value); err != nil {
+		t.Fatalf("SetTag(%s, %s, %s): %v", universe, entity, key, err)
+	}
+}
+
+// expectTag checks that a tag holds the JSON representation of the given
+// value, comparing the decoded values so that formatting does not matter.
+func expectTag(t *testing.T, store tango.TagStore, universe, entity, key string, value any) {
+	t.Helper()
+	raw, exists, err := store.GetTag(context.Background(), universe, entity, key)
+	if err != nil || !exists {
+		t.Fatalf("Expected %s/%s/%s to be set (%v, %v)", universe, entity, key, exists, err)
+	}
+	want, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, expected any
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("Expected %s/%s/%s to hold valid JSON, got %s", universe, entity, key, raw)
+	}
+	if err := json.Unmarshal(want, &expected); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %s/%s/%s to be %s, got %s", universe, entity, key, want, raw)
+	}
+}
+
+// expectMissing checks that a tag is not set.
+func expectMissing(t *testing.T, store tango.TagStore, universe, entity, key string) {
+	t.Helper()
+	raw, exists, err := store.GetTag(context.Background(), universe, entity, key)
+	if err != nil || exists {
+		t.Errorf("Expected %s/%s/%s not to be set, got %s (%v, %v)", universe, entity, key, raw, exists, err)
+	}
+}
+
+func testGetMissing(t *testing.T, store tango.TagStore) {
+	expectMissing(t, store, "1234", "5678", "missing")
+}
+
+func testSetAndGet(t *testing.T, store tango.TagStore) {
+	values := map[string]any{
+		"string": "hello",
+		"number": 3.5,
+		"true":   true,
+		"false":  false,
+		"array":  []any{"a", 1.0},
+		"object": map[string]any{"a": 1.0, "b": []any{}},
+	}
+	for key, value := range values {
+		mustSet(t, store, "1234", "5678", key, value)
+	}
+	for key, value := range values {
+		expectTag(t, store, "1234", "5678", key, value)
+	}
+}
+
+func testUpsert(t *testing.T, store tango.TagStore) {
+	mustSet(t, store, "1234", "5678", "key", "old")
+	mustSet(t, store, "1234", "5678", "key", map[string]any{"new": true})
+	expectTag(t, store, "1234", "5678", "key", map[string]any{"new": true})
+	keys, err := store.ListTags(context.Background(), "1234", "5678")
+	if err != nil || len(keys) != 1 {
+		t.Errorf("Expected the upsert to keep a single tag, got %v (%v)", keys, err)
+	}
+}
+
+func testNull(t *testing.T, store tango.TagStore) {
+	mustSet(t, store, "1234", "5678", "null", nil)
+	expectTag(t, store, "1234", "5678", "null", nil)
+}
+
+func testDelete(t *testing.T, store tango.TagStore) {
+	ctx := context.Background()
+	mustSet(t, store, "1234", "5678", "key", 1)
+	if err := store.DeleteTag(ctx, "1234", "5678", "key"); err != nil {
+		t.Fatal(err)
+	}
+	expectMissing(t, store, "1234", "5678", "key")
+	if err := store.DeleteTag(ctx, "1234", "5678", "key"); err != nil {
+		t.Errorf("Expected deleting a missing tag not to fail, got %v", err)
+	}
+}
+
+func testList(t *testing.T, store tango.TagStore) {
+	ctx := context.Background()
+	keys, err := store.ListTags(ctx, "1234", "5678")
+	if err != nil || len(keys) != 0 {
+		t.Errorf("Expected an empty entity to have no tags, got %v (%v)", keys, err)
+	}
+	for _, key := range []string{"c", "a", "b"} {
+		mustSet(t, store, "1234", "5678", key, key)
+	}
+	keys, err = store.ListTags(ctx, "1234", "5678")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The order of the keys is not part of the contract.
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"a", "b", "c"}) {
+		t.Errorf("Expected keys a, b and c, got %v", keys)
+	}
+}
+
+func testIsolation(t *testing.T, store tango.TagStore) {
+	mustSet(t, store, "1234", "5678", "key", "a")
+	mustSet(t, store, "1234", "8765", "key", "b")
+	mustSet(t, store, "4321", "5678", "key", "c")
+	expectTag(t, store, "1234", "5678", "key", "a")
+	expectTag(t, store, "1234", "8765", "key", "b")
+	expectTag(t, store, "4321", "5678", "key", "c")
+	if err := store.DeleteTag(context.Background(), "1234", "5678", "key"); err != nil {
+		t.Fatal(err)
+	}
+	expectTag(t, store, "1234", "8765", "key", "b")
+	expectTag(t, store, "4321", "5678", "key", "c")
+}
+
+func testConcurrency(t *testing.T, store tango.TagStore) {
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- store.SetTag(context.Background(), "1234", "5678", fmt.Sprintf("key%d", i), i)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Expected concurrent writes to succeed, got %v", err)
+		}
+	}
+	keys, err := store.ListTags(context.Background(), "1234", "5678")
+	if err != nil || len(keys) != writers {
+		t.Errorf("Expected %d tags after concurrent writes, got %v (%v)", writers, keys, err)
+	}
+}
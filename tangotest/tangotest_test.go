@@ -0,0 +1,32 @@
+package tangotest
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"gopkg.makigas.es/tango"
+)
+
+func TestEngine(t *testing.T) {
+	RunStoreTests(t, func(t *testing.T) tango.TagStore {
+		db, err := sql.Open("sqlite3", ":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		// Every connection to an in-memory database opens a new one.
+		db.SetMaxOpenConns(1)
+		if _, err := db.Exec(`CREATE TABLE tags(
+			id INTEGER PRIMARY KEY,
+			universe VARCHAR(64) NOT NULL,
+			entity VARCHAR(64) NOT NULL,
+			key VARCHAR(64) NOT NULL,
+			value TEXT
+		);
+		CREATE UNIQUE INDEX tags_id ON tags(universe, entity, key);`); err != nil {
+			t.Fatal(err)
+		}
+		return tango.NewTagsEngine(db)
+	})
+}
@@ -0,0 +1,130 @@
+package tango
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	tagUpsertTracked = `
+	INSERT INTO {table} (universe, entity, key, value, updated_at, updated_by) VALUES(?, ?, ?, ?, ?, ?)
+	ON CONFLICT(universe, entity, key) DO UPDATE SET value=excluded.value, updated_at=excluded.updated_at, updated_by=excluded.updated_by
+`
+	tagInsert = `
+	INSERT INTO {table} (universe, entity, key, value) VALUES(?, ?, ?, ?)
+	ON CONFLICT(universe, entity, key) DO NOTHING
+`
+	tagInsertTracked = `
+	INSERT INTO {table} (universe, entity, key, value, updated_at, updated_by) VALUES(?, ?, ?, ?, ?, ?)
+	ON CONFLICT(universe, entity, key) DO NOTHING
+`
+	tagMetadata = `SELECT updated_at, updated_by FROM {table} WHERE universe = ? AND entity = ? AND key = ?`
+)
+
+// Metadata describes the last write of a tag, as recorded by an engine
+// configured using WithTracking.
+type Metadata struct {
+	// UpdatedAt is when the tag was last written. It is the zero time if
+	// the tag was written before tracking was enabled.
+	UpdatedAt time.Time
+
+	// UpdatedBy is the actor that last wrote the tag, as given using
+	// AsActor. It is empty if the write had no actor.
+	UpdatedBy string
+}
+
+// upsert stores the given JSON representation as the value of the tag as
+// part of the given transaction, tracking the write if enabled.
+func (tag *Tag) upsert(tx *txn, rawJson string) error {
+	query, args := tagUpsert, []any{tag.universe, tag.entity, tag.key, rawJson}
+	if tag.tags.tracking {
+		query = tagUpsertTracked
+		args = append(args, tx.now, opConfigFrom(tx.ctx).actor)
+	}
+	query = tag.tags.sql(query)
+	traceStatement(tx.ctx, query, redactValue(args)...)
+	_, err := tx.ExecContext(tx.ctx, query, args...)
+	return err
+}
+
+// insert stores the given JSON representation as the value of the tag as
+// part of the given transaction, tracking the write if enabled, unless the
+// tag is already set. It reports whether the tag was stored.
+func (tag *Tag) insert(tx *txn, rawJson string) (bool, error) {
+	query, args := tagInsert, []any{tag.universe, tag.entity, tag.key, rawJson}
+	if tag.tags.tracking {
+		query = tagInsertTracked
+		args = append(args, tx.now, opConfigFrom(tx.ctx).actor)
+	}
+	query = tag.tags.sql(query)
+	traceStatement(tx.ctx, query, redactValue(args)...)
+	result, err := tx.ExecContext(tx.ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// Metadata returns when the tag was last written and by whom, and whether
+// the tag is set. The engine must be configured using WithTracking, or it
+// will fail with ErrTrackingDisabled.
+func (tag *Tag) Metadata() (Metadata, bool, error) {
+	if err := tag.tags.checkUndecorated(); err != nil {
+		return Metadata{}, false, tag.tags.failed("get", tag, err)
+	}
+	if err := tag.tags.checkAddress(tag.universe, tag.entity, tag.key); err != nil {
+		return Metadata{}, false, tag.tags.failed("get", tag, err)
+	}
+	if !tag.tags.tracking {
+		return Metadata{}, false, tag.tags.failed("get", tag, ErrTrackingDisabled)
+	}
+	ctx, cancel := tag.tags.context(context.Background())
+	defer cancel()
+	meta, exists, err := tag.metadata(ctx, tag.tags.db)
+	return meta, exists, tag.tags.failed("get", tag, err)
+}
+
+// metadata reads the tracking columns of the tag using the given querier.
+func (tag *Tag) metadata(ctx context.Context, q querier) (Metadata, bool, error) {
+	var updatedAt sql.NullTime
+	var updatedBy sql.NullString
+	err := q.QueryRowContext(ctx, tag.tags.sql(tagMetadata), tag.universe, tag.entity, tag.key).Scan(&updatedAt, &updatedBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Metadata{}, false, nil
+	} else if err != nil {
+		return Metadata{}, false, err
+	}
+	return Metadata{UpdatedAt: updatedAt.Time, UpdatedBy: updatedBy.String}, true, nil
+}
+
+// SetIfUnmodifiedSince sets the value of the tag only if the tag has not
+// been written after the given time, failing with ErrConflict otherwise, as
+// a single atomic operation. Tags that are not set, or that were last
+// written before tracking was enabled, are considered unmodified. The
+// engine must be configured using WithTracking, or it will fail with
+// ErrTrackingDisabled.
+func (tag *Tag) SetIfUnmodifiedSince(since time.Time, value any, opts ...OpOption) error {
+	tag.warnDeprecated("set")
+	if err := tag.tags.checkUndecorated(); err != nil {
+		return tag.tags.failed("set", tag, err)
+	}
+	if !tag.tags.tracking {
+		return tag.tags.failed("set", tag, ErrTrackingDisabled)
+	}
+	ctx, cancel := tag.tags.context(withOpOptions(context.Background(), opts))
+	defer cancel()
+	err := tag.tags.transaction(ctx, func(tx *txn) error {
+		meta, _, err := tag.metadata(tx.ctx, tx)
+		if err != nil {
+			return err
+		}
+		if meta.UpdatedAt.After(since) {
+			return ErrConflict
+		}
+		return tag.setTx(tx, value)
+	})
+	return tag.tags.finish("set", tag, err)
+}
@@ -0,0 +1,90 @@
+package tango
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTracking(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithTracking())
+
+	tag := tags.Tag("1234", "5678", "prefix")
+	if _, exists, err := tag.Metadata(); err != nil || exists {
+		t.Errorf("Expected tag not to exist (%v, %v)", exists, err)
+	}
+	before := time.Now().Add(-time.Second)
+	if err := tag.Set("!", AsActor("admin")); err != nil {
+		t.Error(err)
+	}
+	meta, exists, err := tag.Metadata()
+	if err != nil {
+		t.Error(err)
+	}
+	if !exists || meta.UpdatedBy != "admin" || meta.UpdatedAt.Before(before) {
+		t.Errorf("Unexpected metadata %+v (%v)", meta, exists)
+	}
+
+	if err := tag.Set("?"); err != nil {
+		t.Error(err)
+	}
+	if meta, _, err := tag.Metadata(); err != nil || meta.UpdatedBy != "" {
+		t.Errorf("Expected actor to be cleared, was %+v (%v)", meta, err)
+	}
+}
+
+func TestTrackingDisabled(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	tag := tags.Tag("1234", "5678", "prefix")
+	if err := tag.Set("!", AsActor("admin")); err != nil {
+		t.Error(err)
+	}
+	if _, _, err := tag.Metadata(); !errors.Is(err, ErrTrackingDisabled) {
+		t.Errorf("Expected ErrTrackingDisabled, got %v", err)
+	}
+}
+
+func TestTrackingSetIfUnmodifiedSince(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithTracking())
+
+	tag := tags.Tag("1234", "5678", "prefix")
+	if err := tag.SetIfUnmodifiedSince(time.Now(), "!"); err != nil {
+		t.Error(err)
+	}
+	meta, _, err := tag.Metadata()
+	if err != nil {
+		t.Error(err)
+	}
+	if err := tag.SetIfUnmodifiedSince(meta.UpdatedAt, "?"); err != nil {
+		t.Error(err)
+	}
+	if err := tag.SetIfUnmodifiedSince(meta.UpdatedAt, "$"); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+	if conflicts := tags.Conflicts(10); len(conflicts) != 1 || conflicts[0].Conflicts != 1 {
+		t.Errorf("Expected the conflict to be counted, was %+v", conflicts)
+	}
+	var result string
+	if _, err := tag.Get(&result); err != nil || result != "?" {
+		t.Errorf("Expected key to resolve to '?', was `%s` (%v)", result, err)
+	}
+
+	if err := NewTagsEngine(db).Tag("1234", "5678", "prefix").SetIfUnmodifiedSince(time.Now(), "!"); !errors.Is(err, ErrTrackingDisabled) {
+		t.Errorf("Expected ErrTrackingDisabled, got %v", err)
+	}
+}
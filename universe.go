@@ -0,0 +1,25 @@
+package tango
+
+// A Universe is a view of the tags engine that is bound to a specific
+// universe. Services that only deal with a single universe (such as a
+// per-guild service object) can hold a Universe instead of having to
+// provide the universe ID on every call.
+type Universe struct {
+	tags     *Tags
+	universe string
+}
+
+// ID returns the identifier of the universe this view is bound to.
+func (u *Universe) ID() string {
+	return u.universe
+}
+
+// Entity returns a handle to the given entity of this universe.
+func (u *Universe) Entity(entity string) *Entity {
+	return u.tags.Entity(u.universe, entity)
+}
+
+// Tag is a shortcut to get a specific tag for an entity of this universe.
+func (u *Universe) Tag(entity, key string) *Tag {
+	return u.tags.Tag(u.universe, entity, key)
+}
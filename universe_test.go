@@ -0,0 +1,62 @@
+package tango
+
+import "testing"
+
+func TestUniverseEntity(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', '5678', 'string', '"hello"')`); err != nil {
+		t.Error(err)
+	}
+
+	guild := tags.ForUniverse("1234")
+	if guild.ID() != "1234" {
+		t.Errorf("Expected universe ID to be 1234, was %s", guild.ID())
+	}
+	list, err := guild.Entity("5678").Bag().Tags()
+	if err != nil {
+		t.Error(err)
+	}
+	if len(list) != 1 || list[0] != "string" {
+		t.Errorf("Expected list to be [string], was %v", list)
+	}
+}
+
+func TestUniverseTag(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	guild := tags.ForUniverse("1234")
+	if err := guild.Tag("5678", "string").Set("hello"); err != nil {
+		t.Error(err)
+	}
+
+	// Should be reachable from the engine using the full address.
+	var result string
+	exists, err := tags.Tag("1234", "5678", "string").Get(&result)
+	if err != nil {
+		t.Error(err)
+	}
+	if !exists {
+		t.Errorf("Expected key to exist")
+	}
+	if result != "hello" {
+		t.Errorf("Expected key to resolve to 'hello', was `%s`", result)
+	}
+
+	// But not from a different universe.
+	exists, err = tags.ForUniverse("4321").Tag("5678", "string").Get(&result)
+	if err != nil {
+		t.Error(err)
+	}
+	if exists {
+		t.Errorf("Expected key not to exist in other universe")
+	}
+}
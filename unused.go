@@ -0,0 +1,63 @@
+package tango
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+var (
+	tagTouch    = `UPDATE {table} SET read_at = ? WHERE universe = ? AND entity = ? AND key = ?`
+	unusedQuery = `SELECT universe, key, COUNT(*) FROM {table}
+	WHERE read_at IS NULL OR read_at < ? GROUP BY universe, key ORDER BY universe, key`
+)
+
+// UnusedKey is the number of values of a key of a universe that have not
+// been read for some time.
+type UnusedKey struct {
+	Universe string
+	Key      string
+	Values   int64
+}
+
+// touch records that the tag has just been read, for a sample of the reads
+// as configured using WithReadTracking. Failing to record it does not make
+// the read fail, but the error is logged.
+func (tag *Tag) touch(ctx context.Context) {
+	rate := tag.tags.readSampleRate
+	if rate <= 0 || rate < 1 && rand.Float64() >= rate {
+		return
+	}
+	_, err := tag.tags.db.ExecContext(ctx, tag.tags.sql(tagTouch), time.Now().UTC(), tag.universe, tag.entity, tag.key)
+	if err != nil && tag.tags.logger != nil {
+		tag.tags.logger.Print(tag.tags.wrapError("touch", tag, err))
+	}
+}
+
+// UnusedKeys returns, for every key of every universe, how many values
+// have not been read since the given time, so that dead data can be found.
+// The engine must be configured using WithReadTracking, and values that
+// have not been read since the tracking was enabled count as unused. If
+// reads are sampled, a value that is seldom read may be reported too.
+func (tags *Tags) UnusedKeys(ctx context.Context, since time.Time) ([]UnusedKey, error) {
+	if err := tags.checkUnscoped(); err != nil {
+		return nil, err
+	}
+	if tags.readSampleRate <= 0 {
+		return nil, ErrTrackingDisabled
+	}
+	rs, err := tags.db.QueryContext(ctx, tags.sql(unusedQuery), since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	var keys []UnusedKey
+	for rs.Next() {
+		var key UnusedKey
+		if err := rs.Scan(&key.Universe, &key.Key, &key.Values); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rs.Err()
+}
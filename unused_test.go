@@ -0,0 +1,60 @@
+package tango
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUnusedKeys(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithReadTracking(1))
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', '1', 'points', '1'),
+		('1234', '2', 'points', '2'),
+		('1234', '1', 'legacy', '3'),
+		('4321', '1', 'points', '4')`); err != nil {
+		t.Error(err)
+	}
+	since := time.Now()
+	var points int
+	if _, err := tags.Tag("1234", "1", "points").Get(&points); err != nil {
+		t.Error(err)
+	}
+
+	keys, err := tags.UnusedKeys(context.Background(), since)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := []UnusedKey{
+		{Universe: "1234", Key: "legacy", Values: 1},
+		{Universe: "1234", Key: "points", Values: 1},
+		{Universe: "4321", Key: "points", Values: 1},
+	}
+	if len(keys) != len(expected) {
+		t.Fatalf("Expected %v, was %v", expected, keys)
+	}
+	for i := range keys {
+		if keys[i] != expected[i] {
+			t.Errorf("Expected %v, was %v", expected[i], keys[i])
+		}
+	}
+}
+
+func TestUnusedKeysDisabled(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, err := tags.UnusedKeys(context.Background(), time.Now()); !errors.Is(err, ErrTrackingDisabled) {
+		t.Errorf("Expected ErrTrackingDisabled, got %v", err)
+	}
+}
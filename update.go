@@ -0,0 +1,81 @@
+package tango
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// updateAttempts is the number of times Update runs before giving up when
+// the transaction fails because of a retryable error.
+const updateAttempts = 3
+
+// An UpdateFunc receives the JSON representation of the keys requested to
+// Update that are set, and returns the values to write for the keys that
+// should change.
+type UpdateFunc func(current map[string]json.RawMessage) (map[string]any, error)
+
+// Update reads the given keys of an entity, calls fn with the values of the
+// keys that are set, and writes the values returned by fn, everything as
+// part of a single transaction. Keys not present in the returned map are
+// left untouched. If the transaction fails with a retryable error, such as
+// a conflict with a concurrent write, the whole read-modify-write cycle is
+// retried, so fn may be called more than once and should have no side
+// effects. Which errors are retryable can be tuned using
+// WithRetryClassifier.
+func (tags *Tags) Update(universe, entity string, keys []string, fn UpdateFunc) error {
+	bag := tags.TagBag(universe, entity)
+	tag := &Tag{universe: universe, entity: entity}
+	if err := tags.checkUndecorated(); err != nil {
+		return tags.failed("update", tag, err)
+	}
+	ctx, done := tags.trace(context.Background(), "update", tag)
+	defer done()
+	ctx, cancel := tags.context(ctx)
+	defer cancel()
+
+	var err error
+	for attempt := 0; attempt < updateAttempts; attempt++ {
+		err = tags.transaction(ctx, func(tx *txn) error {
+			return bag.updateTx(tx, keys, fn)
+		})
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+		if err = tags.wrapError("update", tag, err); !IsRetryable(err) {
+			break
+		}
+		for _, key := range keys {
+			tags.stats.conflict(universe, key)
+		}
+	}
+	return tags.finish("update", tag, err)
+}
+
+// updateTx runs a read-modify-write cycle over the keys of the tagbag as
+// part of the given transaction.
+func (bag *TagBag) updateTx(tx *txn, keys []string, fn UpdateFunc) error {
+	current := make(map[string]json.RawMessage, len(keys))
+	for _, key := range keys {
+		raw, exists, err := bag.Tag(key).fetchTx(tx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			current[key] = json.RawMessage(raw)
+		}
+	}
+	var values map[string]any
+	err := bag.tags.callHook("update", func() (err error) {
+		values, err = fn(current)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for key, value := range values {
+		if err := bag.Tag(key).setTx(tx, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package tango
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestTagsUpdate(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES ('1234', '5678', 'points', '10')`); err != nil {
+		t.Error(err)
+	}
+	err = tags.Update("1234", "5678", []string{"points", "level"}, func(current map[string]json.RawMessage) (map[string]any, error) {
+		if _, ok := current["level"]; ok {
+			t.Errorf("Expected level not to be set")
+		}
+		var points int
+		if err := json.Unmarshal(current["points"], &points); err != nil {
+			return nil, err
+		}
+		return map[string]any{"points": points + 5, "level": points / 10}, nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	e := tags.Entity("1234", "5678")
+	if points, _, err := e.Int("points"); err != nil || points != 15 {
+		t.Errorf("Expected points to be 15, was %d (%v)", points, err)
+	}
+	if level, _, err := e.Int("level"); err != nil || level != 1 {
+		t.Errorf("Expected level to be 1, was %d (%v)", level, err)
+	}
+}
+
+func TestTagsUpdateFailure(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	failure := errors.New("failure")
+	calls := 0
+	err = tags.Update("1234", "5678", []string{"points"}, func(map[string]json.RawMessage) (map[string]any, error) {
+		calls++
+		return map[string]any{"points": 1}, failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected update to fail, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected non retryable errors not to be retried, called %d times", calls)
+	}
+	if exists, err := tags.Tag("1234", "5678", "points").Get(nil); err != nil || exists {
+		t.Errorf("Expected points not to be written (%v, %v)", exists, err)
+	}
+}
+
+func TestTagsUpdateRetry(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	conflict := errors.New("conflict")
+	tags := NewTagsEngine(db, WithRetryClassifier(func(err error) bool {
+		return errors.Is(err, conflict)
+	}))
+
+	calls := 0
+	err = tags.Update("1234", "5678", []string{"points"}, func(map[string]json.RawMessage) (map[string]any, error) {
+		calls++
+		if calls == 1 {
+			return nil, conflict
+		}
+		return map[string]any{"points": 1}, nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected update to be retried once, called %d times", calls)
+	}
+}
@@ -0,0 +1,107 @@
+package tango
+
+import "context"
+
+var (
+	usageUniverses = `SELECT universe, COUNT(*), SUM(length(value)) FROM {table} GROUP BY universe ORDER BY SUM(length(value)) DESC`
+	usageLargest   = `SELECT universe, entity, key, length(value) FROM {table} ORDER BY length(value) DESC LIMIT ?`
+)
+
+// A UsageReport describes how the store is used, to answer capacity
+// questions such as which universes take most of the space.
+type UsageReport struct {
+	// Universes lists every universe, from the largest to the smallest.
+	Universes []UniverseUsage
+
+	// Largest lists the largest values of the store.
+	Largest []ValueUsage
+
+	// Busiest lists the keys with the most operations made by this engine
+	// since it was created. It is only filled if the engine is configured
+	// using WithKeyStats.
+	Busiest []KeyUsage
+}
+
+// UniverseUsage is the space used by a universe.
+type UniverseUsage struct {
+	Universe string
+	Rows     int
+	Bytes    int64
+
+	// Ops is the number of operations over the universe made by this
+	// engine since it was created.
+	Ops int64
+}
+
+// ValueUsage is the space used by the value of a tag.
+type ValueUsage struct {
+	Universe string
+	Entity   string
+	Key      string
+	Bytes    int64
+}
+
+// KeyUsage is the number of operations made over a key of a universe.
+type KeyUsage struct {
+	Universe string
+	Key      string
+	Ops      int64
+}
+
+// Usage returns a report about the usage of the store, listing the top
+// largest values and the top busiest keys.
+func (tags *Tags) Usage(ctx context.Context, top int) (*UsageReport, error) {
+	if err := tags.checkUnscoped(); err != nil {
+		return nil, err
+	}
+	report := &UsageReport{}
+
+	rs, err := tags.db.QueryContext(ctx, tags.sql(usageUniverses))
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		var u UniverseUsage
+		if err := rs.Scan(&u.Universe, &u.Rows, &u.Bytes); err != nil {
+			return nil, err
+		}
+		u.Ops = tags.UniverseStats(u.Universe).Ops
+		report.Universes = append(report.Universes, u)
+	}
+	if err := rs.Err(); err != nil {
+		return nil, err
+	}
+	rs.Close()
+
+	rs, err = tags.db.QueryContext(ctx, tags.sql(usageLargest), top)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	for rs.Next() {
+		var v ValueUsage
+		if err := rs.Scan(&v.Universe, &v.Entity, &v.Key, &v.Bytes); err != nil {
+			return nil, err
+		}
+		report.Largest = append(report.Largest, v)
+	}
+	if err := rs.Err(); err != nil {
+		return nil, err
+	}
+
+	report.Busiest = tags.busiestKeys(top)
+	return report, nil
+}
+
+// busiestKeys returns the keys with the most operations.
+func (tags *Tags) busiestKeys(top int) []KeyUsage {
+	tags.stats.mu.Lock()
+	counts := tags.stats.keys.top(top)
+	tags.stats.mu.Unlock()
+	keys := make([]KeyUsage, len(counts))
+	for i, count := range counts {
+		keys[i] = KeyUsage{Universe: count.universe, Key: count.key, Ops: count.count}
+	}
+	return keys
+}
@@ -0,0 +1,57 @@
+package tango
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUsage(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	tags := NewTagsEngine(db, WithKeyStats())
+
+	if _, err := db.Exec(`INSERT INTO tags(universe, entity, key, value) VALUES
+		('1234', '5678', 'bio', '"a very long biography"'),
+		('1234', '5678', 'points', '1'),
+		('4321', '5678', 'points', '2')`); err != nil {
+		t.Error(err)
+	}
+	var points int
+	for i := 0; i < 3; i++ {
+		if _, err := tags.Tag("4321", "5678", "points").Get(&points); err != nil {
+			t.Error(err)
+		}
+	}
+
+	report, err := tags.Usage(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Universes) != 2 {
+		t.Fatalf("Expected 2 universes, got %+v", report.Universes)
+	}
+	if u := report.Universes[0]; u.Universe != "1234" || u.Rows != 2 || u.Bytes != 24 || u.Ops != 0 {
+		t.Errorf("Unexpected usage %+v", u)
+	}
+	if u := report.Universes[1]; u.Universe != "4321" || u.Rows != 1 || u.Ops != 3 {
+		t.Errorf("Unexpected usage %+v", u)
+	}
+	if len(report.Largest) != 1 || report.Largest[0].Key != "bio" || report.Largest[0].Bytes != 23 {
+		t.Errorf("Unexpected largest values %+v", report.Largest)
+	}
+	if len(report.Busiest) != 1 || report.Busiest[0].Universe != "4321" || report.Busiest[0].Ops != 3 {
+		t.Errorf("Unexpected busiest keys %+v", report.Busiest)
+	}
+
+	// Operations per key are only counted if enabled.
+	untracked := NewTagsEngine(db)
+	if _, err := untracked.Tag("4321", "5678", "points").Get(&points); err != nil {
+		t.Error(err)
+	}
+	if report, err := untracked.Usage(context.Background(), 1); err != nil || len(report.Busiest) != 0 {
+		t.Errorf("Expected no busiest keys, got %+v (%v)", report, err)
+	}
+}
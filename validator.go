@@ -0,0 +1,39 @@
+package tango
+
+import "encoding/json"
+
+// A Validator is called before a tag is set. It receives the key being
+// set, the new value and the JSON representation of the current value, or
+// nil if the tag is not set yet. It returns the value that should actually
+// be persisted, which may be the given value or a rewritten one, or an
+// error if the value should be rejected.
+type Validator func(key string, value any, old json.RawMessage) (any, error)
+
+// validate runs the validators that apply to this tag over the given value
+// and returns the value that should be persisted.
+func (tag *Tag) validate(tx *txn, value any) (any, error) {
+	validators := tag.tags.validators
+	validators = append(validators[:len(validators):len(validators)], tag.tags.universeValidators[tag.universe]...)
+	if len(validators) == 0 {
+		return value, nil
+	}
+
+	var old json.RawMessage
+	raw, exists, err := tag.fetchKey(tx.ctx, tx, tag.key)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		old = json.RawMessage(raw)
+	}
+	for _, validator := range validators {
+		err := tag.tags.callHook("validator", func() (err error) {
+			value, err = validator(tag.key, value, old)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
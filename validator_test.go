@@ -0,0 +1,80 @@
+package tango
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidatorRejects(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	errTooLong := errors.New("prefix too long")
+	tags := NewTagsEngine(db, WithUniverseValidator("1234", func(key string, value any, old json.RawMessage) (any, error) {
+		if s, ok := value.(string); ok && key == "prefix" && len(s) > 3 {
+			return nil, errTooLong
+		}
+		return value, nil
+	}))
+
+	if err := tags.Tag("1234", "5678", "prefix").Set("!!!!"); !errors.Is(err, errTooLong) {
+		t.Errorf("Expected validator to reject the value, got %v", err)
+	}
+	var result string
+	exists, err := tags.Tag("1234", "5678", "prefix").Get(&result)
+	if err != nil {
+		t.Error(err)
+	}
+	if exists {
+		t.Errorf("Expected key not to be persisted")
+	}
+
+	// Other universes are not affected by the validator.
+	if err := tags.Tag("4321", "5678", "prefix").Set("!!!!"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidatorRewrites(t *testing.T) {
+	db, _, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+
+	var olds []string
+	tags := NewTagsEngine(db,
+		WithValidator(func(key string, value any, old json.RawMessage) (any, error) {
+			olds = append(olds, string(old))
+			return value, nil
+		}),
+		WithUniverseValidator("1234", func(key string, value any, old json.RawMessage) (any, error) {
+			if s, ok := value.(string); ok {
+				return strings.ToLower(s), nil
+			}
+			return value, nil
+		}))
+
+	tag := tags.Tag("1234", "5678", "prefix")
+	if err := tag.Set("HELLO"); err != nil {
+		t.Error(err)
+	}
+	if err := tag.Set("WORLD"); err != nil {
+		t.Error(err)
+	}
+	var result string
+	if _, err := tag.Get(&result); err != nil {
+		t.Error(err)
+	}
+	if result != "world" {
+		t.Errorf("Expected key to resolve to 'world', was `%s`", result)
+	}
+	if len(olds) != 2 || olds[0] != "" || olds[1] != `"hello"` {
+		t.Errorf("Expected validator to see old values, saw %v", olds)
+	}
+}
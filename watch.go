@@ -0,0 +1,284 @@
+package tango
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultWatchBuffer is the number of events that can be queued for a
+// subscriber unless the options of the subscription say otherwise.
+const defaultWatchBuffer = 64
+
+// An EventOp is the kind of change described by an Event.
+type EventOp int
+
+const (
+	// EventSet is the event of a tag being set.
+	EventSet EventOp = iota + 1
+
+	// EventDelete is the event of a tag being deleted.
+	EventDelete
+)
+
+// An Event describes a change made to a tag through the engine.
+type Event struct {
+	Op       EventOp
+	Universe string
+	Entity   string
+	Key      string
+
+	// Value is the JSON representation of the new value of the tag. It is
+	// nil for deletions.
+	Value json.RawMessage
+
+	// At is when the change was made. For engines configured using
+	// WithTracking it is the time stored with the change, so it matches the
+	// UpdatedAt of the tag and the position replayed by Replay.
+	At time.Time
+}
+
+// A WatchFilter selects the events received by a subscriber. The fields
+// that are left empty match every event.
+type WatchFilter struct {
+	Universe string
+	Entity   string
+
+	// Key is a pattern for the key of the tag, using the syntax of
+	// path.Match, such as "points:*".
+	Key string
+
+	// Ops are the kinds of changes to receive.
+	Ops []EventOp
+}
+
+// matches returns whether the event passes the filter.
+func (f WatchFilter) matches(event Event) bool {
+	if f.Universe != "" && f.Universe != event.Universe {
+		return false
+	}
+	if f.Entity != "" && f.Entity != event.Entity {
+		return false
+	}
+	if f.Key != "" {
+		if ok, _ := path.Match(f.Key, event.Key); !ok {
+			return false
+		}
+	}
+	if len(f.Ops) == 0 {
+		return true
+	}
+	for _, op := range f.Ops {
+		if op == event.Op {
+			return true
+		}
+	}
+	return false
+}
+
+// An OverflowPolicy tells what to do with the events of a subscription
+// whose buffer is full because the subscriber does not keep up.
+type OverflowPolicy int
+
+const (
+	// DropNewest discards the events that do not fit into the buffer.
+	DropNewest OverflowPolicy = iota
+
+	// DropOldest discards the oldest event of the buffer to make room for
+	// the new one, so that subscribers always receive the latest changes.
+	DropOldest
+
+	// CloseOnOverflow ends the subscription, closing its channel, so that
+	// subscribers that cannot miss events notice it and resynchronise.
+	// Err returns ErrWatchOverflow afterwards.
+	CloseOnOverflow
+)
+
+// WatchOptions tune a subscription made using Subscribe.
+type WatchOptions struct {
+	// Buffer is the number of events that can be queued for the
+	// subscriber. It defaults to 64.
+	Buffer int
+
+	// Overflow is what to do when the buffer is full. By default, new
+	// events are dropped.
+	Overflow OverflowPolicy
+}
+
+// A Subscription receives the events of an engine that pass its filter
+// through the channel C, until the context given to Subscribe is done or
+// the subscription overflows using CloseOnOverflow.
+type Subscription struct {
+	C <-chan Event
+
+	ch      chan Event
+	prefix  string
+	filter  WatchFilter
+	opts    WatchOptions
+	dropped atomic.Int64
+
+	// done is closed when the subscription overflows. The closed flag is
+	// guarded by the mutex of the watchers.
+	done   chan struct{}
+	closed bool
+
+	mu  sync.Mutex
+	err error
+}
+
+// Dropped returns the number of events that were discarded because the
+// buffer of the subscription was full.
+func (sub *Subscription) Dropped() int64 {
+	return sub.dropped.Load()
+}
+
+// Err returns ErrWatchOverflow if the subscription was closed because it
+// overflowed, and nil otherwise.
+func (sub *Subscription) Err() error {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	return sub.err
+}
+
+// send queues the event following the overflow policy. It is called with
+// the mutex of the watchers held.
+func (sub *Subscription) send(ws *watchers, event Event) {
+	select {
+	case sub.ch <- event:
+		return
+	default:
+	}
+	sub.dropped.Add(1)
+	switch sub.opts.Overflow {
+	case DropOldest:
+		select {
+		case <-sub.ch:
+		default:
+		}
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	case CloseOnOverflow:
+		sub.mu.Lock()
+		sub.err = ErrWatchOverflow
+		sub.mu.Unlock()
+		ws.close(sub)
+		close(sub.done)
+	}
+}
+
+// watchers are the subscribers to the events of an engine, which are shared
+// by the scopes of the engine.
+type watchers struct {
+	mu   sync.Mutex
+	subs map[*Subscription]bool
+}
+
+func newWatchers() *watchers {
+	return &watchers{subs: make(map[*Subscription]bool)}
+}
+
+// close ends the subscription, if it is still open. It is called with the
+// mutex held.
+func (ws *watchers) close(sub *Subscription) {
+	if !sub.closed {
+		sub.closed = true
+		delete(ws.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// Watch subscribes to the changes made to tags through this engine, which
+// are sent into the returned channel once they are committed. Only the
+// events that pass the filter are sent, so that subscribers are not woken
+// up for irrelevant changes. The subscription ends, and the channel is
+// closed, when the context is done. Events are delivered as they happen,
+// and they are dropped if the subscriber does not keep up, so they should
+// be consumed quickly. Use Subscribe to choose what happens when the
+// subscriber does not keep up.
+//
+// Only the changes made by this process are received, and maintenance
+// operations that bypass the tags, such as restores and imports, do not
+// send events. Engines returned by Scoped only receive the changes made to
+// the keys of their scope, without the prefix.
+func (tags *Tags) Watch(ctx context.Context, filter WatchFilter) (<-chan Event, error) {
+	sub, err := tags.Subscribe(ctx, filter, WatchOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return sub.C, nil
+}
+
+// Subscribe works like Watch, but returns a Subscription tuned using the
+// given options, which tell how many events can be queued and what to do
+// when the subscriber does not keep up, and which counts the events that
+// were dropped.
+func (tags *Tags) Subscribe(ctx context.Context, filter WatchFilter, opts WatchOptions) (*Subscription, error) {
+	if _, err := path.Match(filter.Key, ""); err != nil {
+		return nil, err
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = defaultWatchBuffer
+	}
+	sub := &Subscription{
+		ch:     make(chan Event, opts.Buffer),
+		prefix: tags.keyPrefix,
+		filter: filter,
+		opts:   opts,
+		done:   make(chan struct{}),
+	}
+	sub.C = sub.ch
+	ws := tags.watchers
+	ws.mu.Lock()
+	ws.subs[sub] = true
+	ws.mu.Unlock()
+	go func() {
+		select {
+		case <-ctx.Done():
+			ws.mu.Lock()
+			ws.close(sub)
+			ws.mu.Unlock()
+		case <-sub.done:
+		}
+	}()
+	return sub, nil
+}
+
+// publish sends the event to the subscribers whose filter it passes.
+func (ws *watchers) publish(event Event) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	for sub := range ws.subs {
+		if !strings.HasPrefix(event.Key, sub.prefix) {
+			continue
+		}
+		scoped := event
+		scoped.Key = strings.TrimPrefix(event.Key, sub.prefix)
+		if sub.filter.matches(scoped) {
+			sub.send(ws, scoped)
+		}
+	}
+}
+
+// notify publishes the events of the tags written as part of a committed
+// transaction.
+func (ws *watchers) notify(writes []txnWrite) {
+	ws.mu.Lock()
+	empty := len(ws.subs) == 0
+	ws.mu.Unlock()
+	if empty {
+		return
+	}
+	for _, w := range writes {
+		event := Event{Op: EventDelete, Universe: w.tag.universe, Entity: w.tag.entity, Key: w.tag.key, At: w.at}
+		if w.exists {
+			event.Op, event.Value = EventSet, json.RawMessage(w.raw)
+		}
+		ws.publish(event)
+	}
+}
@@ -0,0 +1,155 @@
+package tango
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTagsWatch(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	all, err := tags.Watch(ctx, WatchFilter{})
+	if err != nil {
+		t.Error(err)
+	}
+	points, err := tags.Watch(ctx, WatchFilter{Universe: "1234", Key: "points:*", Ops: []EventOp{EventSet}})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if err := tags.Tag("1234", "a", "points:week").Set(10); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("4321", "a", "points:week").Set(20); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "a", "lang").Set("es"); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "a", "points:week").Delete(); err != nil {
+		t.Error(err)
+	}
+	// Deleting a tag that is not set changes nothing, so it sends no event.
+	if err := tags.Tag("1234", "a", "missing").Delete(); err != nil {
+		t.Error(err)
+	}
+
+	if len(all) != 4 {
+		t.Errorf("Expected 4 events, got %d", len(all))
+	}
+	if len(points) != 1 {
+		t.Fatalf("Expected 1 filtered event, got %d", len(points))
+	}
+	event := <-points
+	if event.Op != EventSet || event.Entity != "a" || event.Key != "points:week" || string(event.Value) != "10" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	for i := 0; i < 3; i++ {
+		<-all
+	}
+	if event := <-all; event.Op != EventDelete || event.Value != nil {
+		t.Errorf("Expected a delete event, got %+v", event)
+	}
+
+	cancel()
+	if _, ok := <-all; ok {
+		t.Errorf("Expected the channel to be closed")
+	}
+}
+
+func TestTagsWatchScoped(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := tags.Scoped("plugin:").Watch(ctx, WatchFilter{})
+	if err != nil {
+		t.Error(err)
+	}
+	if err := tags.Tag("1234", "a", "points").Set(10); err != nil {
+		t.Error(err)
+	}
+	if err := tags.Scoped("plugin:").Tag("1234", "a", "points").Set(20); err != nil {
+		t.Error(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	if event := <-events; event.Key != "points" || string(event.Value) != "20" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+}
+
+func TestTagsWatchInvalidPattern(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	if _, err := tags.Watch(context.Background(), WatchFilter{Key: "["}); err == nil {
+		t.Errorf("Expected an invalid pattern to fail")
+	}
+}
+
+func TestTagsSubscribeOverflow(t *testing.T) {
+	db, tags, err := prepareTagEngine()
+	if err != nil {
+		t.Error(err)
+	}
+	defer db.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newest, err := tags.Subscribe(ctx, WatchFilter{}, WatchOptions{Buffer: 2})
+	if err != nil {
+		t.Error(err)
+	}
+	oldest, err := tags.Subscribe(ctx, WatchFilter{}, WatchOptions{Buffer: 2, Overflow: DropOldest})
+	if err != nil {
+		t.Error(err)
+	}
+	closing, err := tags.Subscribe(ctx, WatchFilter{}, WatchOptions{Buffer: 2, Overflow: CloseOnOverflow})
+	if err != nil {
+		t.Error(err)
+	}
+	for i := 1; i <= 3; i++ {
+		if err := tags.Tag("1234", "a", "points").Set(i); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if newest.Dropped() != 1 || oldest.Dropped() != 1 || closing.Dropped() != 1 {
+		t.Errorf("Expected every subscription to drop 1 event, got %d, %d and %d", newest.Dropped(), oldest.Dropped(), closing.Dropped())
+	}
+	if event := <-newest.C; string(event.Value) != "1" {
+		t.Errorf("Expected DropNewest to keep the first event, got %s", event.Value)
+	}
+	if event := <-oldest.C; string(event.Value) != "2" {
+		t.Errorf("Expected DropOldest to drop the first event, got %s", event.Value)
+	}
+	if newest.Err() != nil {
+		t.Errorf("Expected no error, got %v", newest.Err())
+	}
+
+	received := 0
+	for range closing.C {
+		received++
+	}
+	if received != 2 || !errors.Is(closing.Err(), ErrWatchOverflow) {
+		t.Errorf("Expected the subscription to close after 2 events, got %d (%v)", received, closing.Err())
+	}
+	if err := tags.Tag("1234", "a", "points").Set(4); err != nil {
+		t.Errorf("Expected writes to go on after closing, got %v", err)
+	}
+}